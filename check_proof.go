@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/CptPie/DLPP-solver/logger"
+	dimacsParser "github.com/CptPie/DLPP-solver/parser"
+	"github.com/CptPie/DLPP-solver/solver"
+	"github.com/alexflint/go-arg"
+)
+
+var CheckProofArgs struct {
+	File   string `arg:"required,positional" help:"Path to the input file, in DIMACS format"`
+	Proof  string `arg:"required,positional" help:"Path to the proof, text or binary"`
+	Format string `arg:"--format,-f" default:"drat" help:"Proof format: 'drat' or 'lrat' (default: drat)"`
+}
+
+// checkProof verifies that a proof refutes the given DIMACS file, exiting
+// with a non-zero status if it does not
+func checkProof(args []string) {
+	p, err := arg.NewParser(arg.Config{Program: "DLPP-solver check-proof"}, &CheckProofArgs)
+	if err != nil {
+		exitWithError("Could not create argument parser: %v\n", err)
+	}
+	p.MustParse(args)
+
+	kind, err := solver.ParseProofKind(CheckProofArgs.Format)
+	if err != nil {
+		exitWithError("Invalid --format: %v\n", err)
+	}
+
+	parser, err := dimacsParser.NewParser(CheckProofArgs.File)
+	if err != nil {
+		exitWithError("Parser error: %v\n", err)
+	}
+	task, err := parser.Parse()
+	if err != nil {
+		exitWithError("Parser error: %v\n", err)
+	}
+
+	proof, err := os.Open(CheckProofArgs.Proof)
+	if err != nil {
+		exitWithError("Failed to open proof: %v\n", err)
+	}
+	defer proof.Close()
+
+	logger.Info("Checking %s proof %s against %s\n", kind, CheckProofArgs.Proof, CheckProofArgs.File)
+	startTime := time.Now()
+	checker := solver.NewProofChecker(task)
+	if kind == solver.LRAT {
+		err = checker.CheckLRAT(proof)
+	} else {
+		err = checker.CheckDRAT(proof)
+	}
+	logger.Info("%d additions, %d deletions, %d additions checked (%d RAT)\n", checker.Additions, checker.Deletions, checker.Checked, checker.RAT)
+	if checker.Ignored > 0 {
+		logger.Info("Ignored %d deletions of unit clauses\n", checker.Ignored)
+	}
+	logger.Info("Time elapsed: %v\n", time.Since(startTime))
+
+	if err != nil {
+		logger.Info("Proof is NOT VERIFIED: %v\n", err)
+		os.Exit(1)
+	}
+	logger.Info("Proof is VERIFIED\n")
+}
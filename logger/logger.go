@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 )
 
 type LogLevel int
@@ -34,6 +35,44 @@ func SetLevel(level LogLevel) {
 	globalLogger.level = level
 }
 
+// SetOutput sets where the global logger writes to
+func SetOutput(output io.Writer) {
+	globalLogger.output = output
+}
+
+// SetPrefix starts every line the global logger writes with the given
+// prefix, e.g. to mark them as comments for tools reading the output
+func SetPrefix(prefix string) {
+	globalLogger.output = &prefixWriter{output: globalLogger.output, prefix: []byte(prefix), lineStart: true}
+}
+
+// prefixWriter inserts a prefix at the start of every line written through
+// it. Solver workers log concurrently, so the line state and the write are
+// guarded together.
+type prefixWriter struct {
+	output    io.Writer
+	prefix    []byte
+	lineStart bool
+	mu        sync.Mutex
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	buf := make([]byte, 0, len(p)+len(w.prefix))
+	for _, b := range p {
+		if w.lineStart {
+			buf = append(buf, w.prefix...)
+		}
+		buf = append(buf, b)
+		w.lineStart = b == '\n'
+	}
+	if _, err := w.output.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
 // ParseLevel converts a string to a LogLevel
 func ParseLevel(levelStr string) LogLevel {
 	switch levelStr {
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/CptPie/DLPP-solver/logger"
@@ -14,35 +19,185 @@ import (
 )
 
 var Args struct {
-	File          string `arg:"required,positional" help:"Path to the input file, in DIMACS format"`
-	LogLevel      string `arg:"--log-level,-l" default:"none" help:"Log level: 'none', 'steps', or 'full' (default: none)"`
-	Parallel      bool   `arg:"--parallel,-p" help:"Enable parallel solving"`
-	Threads       int    `arg:"--threads,-t" help:"Number of worker threads (default: half of available CPUs, requires --parallel)"`
-	ParallelDepth int    `arg:"--parallel-depth,-d" default:"0" help:"Only parallelize splits up to this depth (0 = unlimited, requires --parallel)"`
-	Optimum       bool   `arg:"--optimum,-o" help:"Find minimal solution (fewest variable assignments, requires --parallel)"`
-	NumFiles      int    `arg:"--NumFiles,-n" default: "-1" help:"Number of files to be solved in case of 'File' being a folder (default: all Files)"`
+	File            string        `arg:"required,positional" help:"Path to the input file, in DIMACS format"`
+	LogLevel        string        `arg:"--log-level,-l" default:"none" help:"Log level: 'none', 'steps', or 'full' (default: none)"`
+	Parallel        bool          `arg:"--parallel,-p" help:"Enable parallel solving"`
+	Threads         int           `arg:"--threads,-t" help:"Number of worker threads (default: half of available CPUs, requires --parallel or --portfolio)"`
+	ParallelDepth   int           `arg:"--parallel-depth,-d" default:"0" help:"Only parallelize splits up to this depth (0 = unlimited, requires --parallel)"`
+	Portfolio       bool          `arg:"--portfolio" help:"Run a complete search per worker thread, each with a different configuration, the first to finish wins"`
+	Optimum         bool          `arg:"--optimum,-o" help:"Find minimal solution (fewest variable assignments, requires --parallel)"`
+	Cube            bool          `arg:"--cube" help:"Cube-and-conquer: split the problem into cubes with lookahead and solve them with --algorithm (requires --parallel)"`
+	CubeDepth       int           `arg:"--cube-depth" default:"0" help:"Most splits per cube (0 = unlimited, requires --cube or --icnf)"`
+	CubeCount       int           `arg:"--cube-count" default:"0" help:"Most cubes (default: 16 per worker thread, requires --cube or --icnf)"`
+	ICNF            string        `arg:"--icnf" help:"Write the problem and its lookahead cubes to this iCNF file instead of solving it (not supported for folders)"`
+	NumFiles        int           `arg:"--NumFiles,-n" default:"-1" help:"Number of files to be solved in case of 'File' being a folder (default: all Files)"`
+	Algorithm       string        `arg:"--algorithm,-a" default:"dpll" help:"Sequential solving algorithm: 'dpll', 'cdcl' or 'lookahead' (default: dpll)"`
+	Heuristic       string        `arg:"--heuristic" help:"Branching heuristic: 'dlcs', 'dlis', 'moms', 'jw', 'jw2', 'random' or 'vsids' (default: vsids for cdcl, dlcs otherwise)"`
+	Seed            int64         `arg:"--seed" default:"1" help:"Seed for randomized decisions (default: 1)"`
+	Polarity        string        `arg:"--polarity" default:"heuristic" help:"Polarity of decisions: 'heuristic', 'positive' or 'negative' (default: heuristic)"`
+	Restart         string        `arg:"--restart" default:"none" help:"Restart policy: 'none', 'fixed', 'luby', 'geometric' or 'glucose' (default: none, requires --algorithm cdcl)"`
+	RestartInterval int           `arg:"--restart-interval" default:"100" help:"Conflicts between fixed restarts, Luby unit, first geometric interval or glucose LBD window (default: 100)"`
+	RestartFactor   float64       `arg:"--restart-factor" default:"1.5" help:"Growth of the interval after every geometric restart (default: 1.5)"`
+	ReduceInterval  int           `arg:"--reduce-interval" default:"2000" help:"Learned clauses before the first CDCL clause database reduction, or imported clauses before a parallel worker reduces them, 0 keeps all (default: 2000)"`
+	Core            bool          `arg:"--core" help:"For UNSAT results, report a subset of the clauses that is unsatisfiable on its own"`
+	MUS             bool          `arg:"--mus" help:"Shrink the reported core to a minimal unsatisfiable subset (implies --core)"`
+	Proof           string        `arg:"--proof" help:"Write a DRAT proof of UNSAT results to this file (not supported with --parallel or folders)"`
+	ProofFormat     string        `arg:"--proof-format" default:"text" help:"Proof format: 'text' or 'binary' (default: text)"`
+	NoVerify        bool          `arg:"--no-verify" help:"Do not check that a found solution satisfies all clauses"`
+	Format          string        `arg:"--format" default:"text" help:"Output format: 'text' or 'competition' (s/v lines and exit codes 10/20/0) (default: text)"`
+	Timeout         time.Duration `arg:"--timeout" help:"Give up on a file after this time, e.g. 30s or 5m, with result UNKNOWN (default: no limit)"`
+	MaxDecisions    int           `arg:"--max-decisions" help:"Give up on a file after this many decisions with result UNKNOWN (default: no limit)"`
+	MaxConflicts    int           `arg:"--max-conflicts" help:"Give up on a file after this many conflicts with result UNKNOWN (default: no limit)"`
+	Output          string        `arg:"--output" default:"text" help:"Result output: 'text' or 'json' (one JSON object per file on stdout, log output on stderr) (default: text)"`
+	ShareSize       int           `arg:"--share-size" default:"8" help:"Longest learned clause the parallel workers share, 0 to share none (default: 8, requires --parallel)"`
+	ShareLBD        int           `arg:"--share-lbd" default:"4" help:"Most decision levels the literals of a shared clause may span (default: 4, requires --parallel)"`
+	MemLimit        string        `arg:"--mem-limit" help:"Give up with result UNKNOWN once queued work items, checkpoints and worker clauses need about this much memory, e.g. 512MB or 2GB. Only stops the search: nothing is spilled to disk and the queue stays at 4 work items per worker (default: no limit, requires --parallel)"`
+	DumpState       string        `arg:"--dump-state" help:"If the search stops early, write the open part of the search space to this file (not supported for folders)"`
+	Resume          string        `arg:"--resume" help:"Continue the search from a state written with --dump-state (not supported for folders)"`
 }
 
+var algorithm solver.Algorithm
+var proofFormat solver.ProofFormat
+var options = solver.DefaultOptions()
+
 func main() {
+	// Subcommands have their own arguments, which go-arg cannot mix with the positional file
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check-proof":
+			checkProof(os.Args[2:])
+			return
+		case "verify":
+			verifyModel(os.Args[2:])
+			return
+		}
+	}
+
 	// read cli argument
 	arg.MustParse(&Args)
 
 	// Set log level
 	logger.SetLevel(logger.ParseLevel(Args.LogLevel))
 
+	// In competition format everything but the result lines is a comment
+	if Args.Format != "text" && Args.Format != "competition" {
+		exitWithError("Invalid --format: unknown output format '%s', expected 'text' or 'competition'\n", Args.Format)
+	}
+	if Args.Format == "competition" {
+		logger.SetPrefix("c ")
+	}
+
+	// In JSON output stdout only carries the results
+	if Args.Output != "text" && Args.Output != "json" {
+		exitWithError("Invalid --output: unknown result output '%s', expected 'text' or 'json'\n", Args.Output)
+	}
+	if Args.Output == "json" {
+		logger.SetOutput(os.Stderr)
+		if Args.Format != "text" {
+			exitWithError("Invalid --output: json cannot be combined with --format competition\n")
+		}
+	}
+
+	// Select the sequential solving algorithm
+	var err error
+	algorithm, err = solver.ParseAlgorithm(Args.Algorithm)
+	if err != nil {
+		exitWithError("Invalid --algorithm: %v\n", err)
+	}
+
+	// Select the branching heuristic, clause learning works best with activity based decisions
+	if Args.Heuristic == "" {
+		if algorithm == solver.CDCL && (!Args.Parallel || Args.Cube) {
+			options.Heuristic = solver.VSIDS
+		}
+	} else {
+		options.Heuristic, err = solver.ParseHeuristic(Args.Heuristic)
+		if err != nil {
+			exitWithError("Invalid --heuristic: %v\n", err)
+		}
+	}
+	options.Seed = Args.Seed
+	options.Polarity, err = solver.ParsePolarity(Args.Polarity)
+	if err != nil {
+		exitWithError("Invalid --polarity: %v\n", err)
+	}
+
+	// Select the restart policy
+	options.Restart, err = solver.ParseRestart(Args.Restart)
+	if err != nil {
+		exitWithError("Invalid --restart: %v\n", err)
+	}
+	if options.Restart != solver.NoRestarts && (algorithm != solver.CDCL || (Args.Parallel && !Args.Cube)) {
+		logger.Info("Warning: --restart requires --algorithm cdcl without --parallel or with --cube, ignoring\n")
+	}
+	options.RestartInterval = Args.RestartInterval
+	options.RestartFactor = Args.RestartFactor
+	options.ReduceInterval = Args.ReduceInterval
+	options.MaxDecisions = Args.MaxDecisions
+	options.MaxConflicts = Args.MaxConflicts
+	options.ShareSize = Args.ShareSize
+	options.ShareLBD = Args.ShareLBD
+	if Args.MemLimit != "" {
+		options.MemLimit, err = parseSize(Args.MemLimit)
+		if err != nil {
+			exitWithError("Invalid --mem-limit: %v\n", err)
+		}
+	}
+
+	// Select the proof format
+	proofFormat, err = solver.ParseProofFormat(Args.ProofFormat)
+	if err != nil {
+		exitWithError("Invalid --proof-format: %v\n", err)
+	}
+
+	// Both parallel modes split the threads differently
+	if Args.Parallel && Args.Portfolio {
+		exitWithError("Invalid --portfolio: cannot be combined with --parallel\n")
+	}
+
 	// Check if parallel mode is enabled
 	if !Args.Parallel {
 		// Warn if user specified parallel-only flags
-		if Args.Threads > 0 {
-			fmt.Println("Warning: --threads requires --parallel flag, ignoring")
+		if Args.Threads > 0 && !Args.Portfolio {
+			logger.Info("Warning: --threads requires --parallel or --portfolio flag, ignoring\n")
 		}
 		if Args.ParallelDepth > 0 {
-			fmt.Println("Warning: --parallel-depth requires --parallel flag, ignoring")
+			logger.Info("Warning: --parallel-depth requires --parallel flag, ignoring\n")
 		}
 		if Args.Optimum {
-			fmt.Println("Warning: --optimum requires --parallel flag, ignoring")
+			logger.Info("Warning: --optimum requires --parallel flag, ignoring\n")
+			Args.Optimum = false
+		}
+		if Args.MemLimit != "" {
+			logger.Info("Warning: --mem-limit requires --parallel flag, ignoring\n")
+		}
+		if Args.Cube {
+			logger.Info("Warning: --cube requires --parallel flag, ignoring\n")
+			Args.Cube = false
 		}
 	} else {
+		if algorithm != solver.DPLL && !Args.Cube {
+			logger.Info("Warning: --algorithm %s is not supported with --parallel, using dpll\n", algorithm)
+		}
+		if algorithm != solver.DPLL && Args.Cube && Args.Optimum {
+			logger.Info("Warning: --algorithm %s is not supported with --optimum, solving the cubes with dpll\n", algorithm)
+			algorithm = solver.DPLL
+		}
+		if Args.Proof != "" {
+			logger.Info("Warning: --proof is not supported with --parallel, ignoring\n")
+			Args.Proof = ""
+		}
+
+		if Args.ParallelDepth > 0 {
+			logger.Info("Parallelizing only up to depth %d\n", Args.ParallelDepth)
+		}
+	}
+	if Args.Portfolio && Args.Proof != "" {
+		logger.Info("Warning: --proof is not supported with --portfolio, ignoring\n")
+		Args.Proof = ""
+	}
+
+	if Args.Parallel || Args.Portfolio {
 		// Set thread count - default to half of available CPUs if not specified
 		if Args.Threads == 0 {
 			Args.Threads = runtime.NumCPU() / 2
@@ -51,21 +206,46 @@ func main() {
 			}
 		}
 		logger.Info("Using %d worker threads\n", Args.Threads)
-		if Args.ParallelDepth > 0 {
-			logger.Info("Parallelizing only up to depth %d\n", Args.ParallelDepth)
-		}
+	}
+	if Args.CubeCount == 0 {
+		Args.CubeCount = 16 * max(Args.Threads, 1)
+	}
+
+	// Proofs cover a search of the whole problem, a resumed run only searches what is left
+	if Args.Proof != "" && Args.Resume != "" {
+		logger.Info("Warning: --proof is not supported with --resume, ignoring\n")
+		Args.Proof = ""
 	}
 
+	// Interrupting stops the search with a report of how far it got, a second
+	// interrupt terminates right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	fileInfo, err := os.Stat(Args.File)
 	if err != nil {
-		fmt.Printf("Failed to open path: %s, no such file or directory\n", Args.File)
-		os.Exit(1)
+		exitWithError("Failed to open path: %s, no such file or directory\n", Args.File)
 	}
 	if fileInfo.IsDir() {
+		if Args.Proof != "" {
+			logger.Info("Warning: --proof is not supported for folders, ignoring\n")
+			Args.Proof = ""
+		}
+		if Args.DumpState != "" || Args.Resume != "" {
+			logger.Info("Warning: --dump-state and --resume are not supported for folders, ignoring\n")
+			Args.DumpState = ""
+			Args.Resume = ""
+		}
+		if Args.ICNF != "" {
+			logger.Info("Warning: --icnf is not supported for folders, ignoring\n")
+			Args.ICNF = ""
+		}
 		dir, err := os.Open(Args.File)
 		if err != nil {
-			fmt.Printf("Failed to open path: %s, no such file or directory\n", Args.File)
-			os.Exit(1)
+			exitWithError("Failed to open path: %s, no such file or directory\n", Args.File)
 		}
 
 		var numFiles int
@@ -76,96 +256,318 @@ func main() {
 		}
 
 		files, err := dir.Readdir(numFiles)
-		fmt.Printf("Folder solve mode, solving %d files\n", len(files))
+		logger.Info("Folder solve mode, solving %d files\n", len(files))
 		startTime := time.Now()
 		i := 0
 		for _, f := range files {
+			if ctx.Err() != nil {
+				logger.Info("Interrupted, skipping the remaining %d files\n", len(files)-i)
+				break
+			}
 			i++
-			analyze(Args.File + f.Name())
-			fmt.Printf("%d/%d done\n\n", i, len(files))
+			analyze(ctx, Args.File+f.Name())
+			logger.Info("%d/%d done\n\n", i, len(files))
 		}
 		endTime := time.Now()
 		dur := endTime.Sub(startTime)
-		avg := dur / time.Duration(len(files))
+		avg := dur / time.Duration(max(i, 1))
 
-		fmt.Printf("Solving of %d files took %v; Average: %v\n", len(files), dur, avg)
+		logger.Info("Solving of %d files took %v; Average: %v\n", i, dur, avg)
 	} else {
-		analyze(Args.File)
+		result := analyze(ctx, Args.File)
+		if Args.Format == "competition" {
+			os.Exit(competitionExitCode(result))
+		}
 	}
 }
 
-func analyze(fileName string) {
-	fmt.Printf("Analyzing file %s\n", fileName)
+func analyze(ctx context.Context, fileName string) solver.Result {
+	logger.Info("Analyzing file %s\n", fileName)
 	startTime := time.Now()
 	// create parser object
 	parser, err := dimacsParser.NewParser(fileName)
 	if err != nil {
-		fmt.Printf("Parser error: %v\n", err)
-		os.Exit(1)
+		exitWithError("Parser error: %v\n", err)
 	}
 
 	// parse input file
 	task, err := parser.Parse()
 	if err != nil {
-		fmt.Printf("Parser error: %v\n", err)
-		os.Exit(1)
+		exitWithError("Parser error: %v\n", err)
 	}
 
 	// write debug file showing the parser output
 	f, err := os.Create("parser.out")
 	if err != nil {
-		fmt.Printf("Could not create parser output file: %v\n", err)
-		os.Exit(1)
+		exitWithError("Could not create parser output file: %v\n", err)
 	}
 	defer f.Close()
 
 	_, err = f.WriteString(utils.JSONString(task))
 	if err != nil {
-		fmt.Printf("Could not create parser output file: %v\n", err)
-		os.Exit(1)
+		exitWithError("Could not create parser output file: %v\n", err)
 	}
 
 	// Verify DIMACS compliance
 	err = task.Verify()
 	if err != nil {
-		fmt.Printf("Parsing result is not valid: %v\n", err)
-		os.Exit(1)
+		exitWithError("Parsing result is not valid: %v\n", err)
+	}
+
+	// Offline cube-and-conquer only needs the cubes
+	if Args.ICNF != "" {
+		writeCubes(ctx, task)
+		return solver.UNKNOWN
 	}
 
 	var result solver.Result
 	var solution *dimacsParser.Clause
 	var workCopy []*dimacsParser.Clause
+	var stats solver.Stats
+
+	// The timeout applies to every file on its own
+	if Args.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, Args.Timeout)
+		defer cancel()
+	}
+
+	// A resumed run only searches the cubes an earlier run left open
+	state := newSearchState(fileName)
+	if Args.Resume != "" {
+		state = loadState(Args.Resume, fileName)
+	}
 
 	// Solve
 	if Args.Parallel {
 		// Use parallel solver
-		parallelSolver := solver.NewParallelSolver(task, Args.Threads, Args.ParallelDepth, Args.Optimum)
-		result, solution = parallelSolver.Solve()
+		parallelSolver := solver.NewParallelSolver(task, Args.Threads, Args.ParallelDepth, Args.Optimum, options)
+		addClauses(parallelSolver.AddClause, state.Clauses)
+		var cuber *solver.Cuber
+		if Args.Cube {
+			cuber = solver.NewCuber(task, Args.CubeDepth, Args.CubeCount)
+			addClauses(cuber.AddClause, state.Clauses)
+			parallelSolver.Backend = algorithm
+		}
+		result, solution = solveCubes(ctx, state, &stats, func(cube []solver.Lit) (solver.Result, *dimacsParser.Clause, [][]solver.Lit) {
+			if cuber != nil {
+				// The lookahead splits the part of the search space left under the cube
+				parallelSolver.Cubes = cuber.Cubes(ctx, cube...)
+			}
+			result, solution := parallelSolver.Solve(ctx, cube...)
+			stats.Merge(parallelSolver.Stats)
+			return result, solution, parallelSolver.OpenCubes()
+		})
 
 		// Get last examined work item for UNSAT debugging
 		lastWorkItem := parallelSolver.GetLastWorkItem()
 		if lastWorkItem != nil {
-			workCopy = lastWorkItem.WorkCopy
+			workCopy = parallelSolver.OpenClauses(lastWorkItem)
 			// For UNSAT cases, use the last examined solution instead of nil
-			if result == solver.UNSATISFIABLE && lastWorkItem.Solution != nil {
-				solution = lastWorkItem.Solution
+			if result == solver.UNSATISFIABLE {
+				solution = solver.ClauseFromLits(lastWorkItem.Path)
+			}
+		}
+	} else if Args.Portfolio {
+		// Run complete searches with different configurations side by side
+		portfolioSolver := solver.NewPortfolioSolver(task, solver.PortfolioConfigs(Args.Threads, algorithm, options))
+		addClauses(portfolioSolver.AddClause, state.Clauses)
+		result, solution = solveCubes(ctx, state, &stats, func(cube []solver.Lit) (solver.Result, *dimacsParser.Clause, [][]solver.Lit) {
+			result, solution := portfolioSolver.Solve(ctx, cube...)
+			stats.Merge(portfolioSolver.Stats)
+			// The searches do not split the cube, a stopped cube is searched again
+			return result, solution, [][]solver.Lit{cube}
+		})
+	} else if algorithm == solver.CDCL {
+		// Use sequential solver with clause learning
+		cdclSolver := solver.NewCDCLSolver(task, options)
+		addClauses(cdclSolver.AddClause, state.Clauses)
+		proofFile, proof := openProof()
+		cdclSolver.Proof = proof
+		result, solution = solveCubes(ctx, state, &stats, func(cube []solver.Lit) (solver.Result, *dimacsParser.Clause, [][]solver.Lit) {
+			cdclSolver.Solve(ctx, cube...)
+			stats.Merge(cdclSolver.Stats)
+			// Clause learning does not split the cube, a stopped cube is searched again with the learned clauses
+			return cdclSolver.Result, cdclSolver.Solution, [][]solver.Lit{cube}
+		})
+		closeProof(proofFile, proof)
+		workCopy = cdclSolver.WorkCopy
+		if result == solver.UNKNOWN {
+			for _, clause := range cdclSolver.LearnedClauses() {
+				state.Clauses = append(state.Clauses, intsFromLits(clause))
 			}
 		}
 	} else {
-		// Use sequential solver
-		sequentialSolver := solver.NewSolver(task)
-		sequentialSolver.Solve()
+		// Use sequential solver, optionally splitting on the variable a lookahead scores best
+		newSolver := solver.NewSolver
+		if algorithm == solver.LOOKAHEAD {
+			newSolver = solver.NewLookaheadSolver
+		}
+		sequentialSolver := newSolver(task, options)
+		addClauses(sequentialSolver.AddClause, state.Clauses)
+		proofFile, proof := openProof()
+		sequentialSolver.Proof = proof
+		result, solution = solveCubes(ctx, state, &stats, func(cube []solver.Lit) (solver.Result, *dimacsParser.Clause, [][]solver.Lit) {
+			sequentialSolver.Solve(ctx, cube...)
+			stats.Merge(sequentialSolver.Stats)
+			return sequentialSolver.Result, sequentialSolver.Solution, sequentialSolver.OpenCubes()
+		})
+		closeProof(proofFile, proof)
 		workCopy = sequentialSolver.WorkCopy
-		result = sequentialSolver.Result
-		solution = sequentialSolver.Solution
-
 	}
 	endTime := time.Now()
 	logger.Info("Finished analysis. Problem is %s ", result)
 	if result == solver.SATISFIABLE {
 		logger.Info(" Found solution: %s\n", solution)
+		if !Args.NoVerify {
+			verifySolution(task, solution)
+		}
 	} else if result == solver.UNSATISFIABLE {
 		logger.Info(" Last examined solution: %s\nOpen clauses to solve: %s\n", solution, workCopy)
+		if Args.Core || Args.MUS {
+			reportCore(ctx, task)
+		}
+	} else {
+		logger.Info(" Search stopped before a result was found\n")
+		if Args.Optimum && solution != nil {
+			logger.Info("Best solution so far (%d variables): %s\n", len(solution.Vars), solution)
+		}
+		if Args.DumpState != "" {
+			writeState(Args.DumpState, state)
+		}
 	}
 	logger.Info("Time elapsed: %v\n", endTime.Sub(startTime))
+	logStats(stats)
+
+	if Args.Format == "competition" {
+		printCompetitionResult(task, result, solution)
+	}
+	if Args.Output == "json" {
+		printJSONResult(fileName, task, result, solution, endTime.Sub(startTime), stats)
+	}
+	return result
+}
+
+// exitWithError reports an error through the logger and exits. With
+// --output json it goes to stderr, with --format competition it is a comment.
+func exitWithError(format string, args ...interface{}) {
+	logger.Error(format, args...)
+	os.Exit(1)
+}
+
+// verifySolution checks a found solution against the parsed clauses and
+// exits if it does not satisfy them, the solver has a bug in that case
+func verifySolution(task *dimacsParser.Task, solution *dimacsParser.Clause) {
+	err := solver.Verify(task, solution)
+	if err != nil {
+		logger.Error("Solution is wrong: %v\n", err)
+		os.Exit(1)
+	}
+	logger.Step("Solution satisfies all %d clauses\n", len(task.Clauses))
+}
+
+// openProof creates the proof file requested with --proof, the writer is nil if none was requested
+func openProof() (*os.File, *solver.ProofWriter) {
+	if Args.Proof == "" {
+		return nil, nil
+	}
+	f, err := os.Create(Args.Proof)
+	if err != nil {
+		exitWithError("Could not create proof file: %v\n", err)
+	}
+	return f, solver.NewProofWriter(f, proofFormat)
+}
+
+func closeProof(f *os.File, proof *solver.ProofWriter) {
+	if proof == nil {
+		return
+	}
+	err := proof.Flush()
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		exitWithError("Could not write proof file: %v\n", err)
+	}
+	logger.Info("Proof written to %s\n", Args.Proof)
+}
+
+// writeCubes splits the task into cubes with lookahead and writes both to
+// the iCNF file requested with --icnf
+func writeCubes(ctx context.Context, task *dimacsParser.Task) {
+	cuber := solver.NewCuber(task, Args.CubeDepth, Args.CubeCount)
+	cubes := cuber.Cubes(ctx)
+
+	f, err := os.Create(Args.ICNF)
+	if err != nil {
+		exitWithError("Could not create iCNF file: %v\n", err)
+	}
+	err = solver.WriteICNF(f, task, cubes)
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		exitWithError("Could not write iCNF file: %v\n", err)
+	}
+	logger.Info("Wrote %d cubes to %s\n", len(cubes), Args.ICNF)
+}
+
+// reportCore prints the clauses of an unsatisfiable subset of the task,
+// minimized to a MUS if requested. The budgets were meant for the search
+// and do not apply to the checks, a timeout or interrupt stops them though.
+func reportCore(ctx context.Context, task *dimacsParser.Task) {
+	coreOptions := options
+	coreOptions.MaxDecisions, coreOptions.MaxConflicts = 0, 0
+	if Args.Heuristic == "" {
+		coreOptions.Heuristic = solver.VSIDS
+	}
+	extractor := solver.NewCoreExtractor(task, coreOptions)
+
+	result, core := extractor.Core(ctx)
+	if result == solver.UNKNOWN {
+		logger.Info("Core extraction stopped before a core was found\n")
+		return
+	}
+	if result != solver.UNSATISFIABLE {
+		logger.Error("Core extraction found the problem %s\n", result)
+		return
+	}
+
+	kind := "Unsatisfiable core"
+	if Args.MUS {
+		var minimal bool
+		core, minimal = extractor.Minimize(ctx, core)
+		kind = "Minimal unsatisfiable subset"
+		if !minimal {
+			kind = "Unsatisfiable core (minimization stopped, may not be minimal)"
+		}
+	}
+
+	logger.Info("%s with %d of %d clauses (%d checks):\n", kind, len(core), len(task.Clauses), extractor.Checks)
+	for _, index := range core {
+		clause := task.Clauses[index]
+		logger.Info("  clause %d (line %d): %s\n", index, clause.Line, clause)
+	}
+}
+
+// parseSize parses a number of bytes with an optional unit of KB, MB or GB
+// (powers of 1024, the B may be left out)
+func parseSize(size string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"B", 1}}
+
+	number, factor := strings.ToUpper(strings.TrimSpace(size)), int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, factor = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix)), unit.factor
+			break
+		}
+	}
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("'%s' is not a positive size, expected e.g. 512MB or 2GB", size)
+	}
+	return value * factor, nil
 }
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	dimacsParser "github.com/CptPie/DLPP-solver/parser"
+	"github.com/CptPie/DLPP-solver/solver"
+)
+
+// captureStdout returns what a function prints to the standard output
+func captureStdout(t *testing.T, print func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	print()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestPrintCompetitionResult(t *testing.T) {
+	// Every third variable is false, the model leaves the last one out
+	const numVars = 120
+	task := &dimacsParser.Task{NumVars: numVars}
+	solution := &dimacsParser.Clause{}
+	for id := 1; id < numVars; id++ {
+		solution.Vars = append(solution.Vars, dimacsParser.Variable{ID: id, Negated: id%3 == 0})
+	}
+
+	out := captureStdout(t, func() { printCompetitionResult(task, solver.SATISFIABLE, solution) })
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if lines[0] != "s SATISFIABLE" {
+		t.Fatalf("first line %q, want the s line", lines[0])
+	}
+	if len(lines) < 3 {
+		t.Fatalf("%d v lines for %d variables, want them wrapped", len(lines)-1, numVars)
+	}
+
+	literals := make([]string, 0)
+	for _, line := range lines[1:] {
+		if len(line) > 78 {
+			t.Errorf("v line with %d characters: %q", len(line), line)
+		}
+		fields := strings.Fields(line)
+		if fields[0] != "v" {
+			t.Fatalf("line %q does not start with v", line)
+		}
+		literals = append(literals, fields[1:]...)
+	}
+	if literals[len(literals)-1] != "0" {
+		t.Fatalf("model ends with %q instead of 0", literals[len(literals)-1])
+	}
+	literals = literals[:len(literals)-1]
+	if len(literals) != numVars {
+		t.Fatalf("%d literals, want one for each of the %d variables", len(literals), numVars)
+	}
+	for i, literal := range literals {
+		want := strconv.Itoa(i + 1)
+		if (i+1)%3 == 0 || i+1 == numVars {
+			want = "-" + want
+		}
+		if literal != want {
+			t.Errorf("literal %d is %s, want %s", i+1, literal, want)
+		}
+	}
+
+	for result, want := range map[solver.Result]string{
+		solver.UNSATISFIABLE: "s UNSATISFIABLE\n",
+		solver.UNKNOWN:       "s UNKNOWN\n",
+	} {
+		if out := captureStdout(t, func() { printCompetitionResult(task, result, nil) }); out != want {
+			t.Errorf("%s printed %q, want %q", result, out, want)
+		}
+	}
+}
+
+func TestCompetitionExitCode(t *testing.T) {
+	tests := []struct {
+		result solver.Result
+		want   int
+	}{
+		{solver.SATISFIABLE, 10},
+		{solver.UNSATISFIABLE, 20},
+		{solver.UNKNOWN, 0},
+	}
+	for _, tt := range tests {
+		if got := competitionExitCode(tt.result); got != tt.want {
+			t.Errorf("competitionExitCode(%s) = %d, want %d", tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestPrintJSONResult(t *testing.T) {
+	task := &dimacsParser.Task{Name: "example", NumVars: 3, Clauses: make([]*dimacsParser.Clause, 2)}
+	solution := &dimacsParser.Clause{Vars: []dimacsParser.Variable{{ID: 1}, {ID: 3, Negated: true}}}
+	stats := solver.Stats{Decisions: 2, Propagations: 5, Workers: 1}
+
+	out := captureStdout(t, func() {
+		printJSONResult("example.cnf", task, solver.SATISFIABLE, solution, 1500*time.Millisecond, stats)
+	})
+	if strings.Count(out, "\n") != 1 || !strings.HasSuffix(out, "\n") {
+		t.Errorf("result %q is not a single line", out)
+	}
+	var res map[string]any
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("result %q is not JSON: %v", out, err)
+	}
+	want := map[string]any{
+		"file":        "example.cnf",
+		"name":        "example",
+		"numVars":     3.0,
+		"numClauses":  2.0,
+		"result":      "SATISFIABLE",
+		"timeSeconds": 1.5,
+	}
+	for key, value := range want {
+		if res[key] != value {
+			t.Errorf("%s is %v, want %v", key, res[key], value)
+		}
+	}
+	// Variables the solution leaves open are false
+	model, _ := res["model"].([]any)
+	if !slices.Equal(model, []any{1.0, -2.0, -3.0}) {
+		t.Errorf("model %v, want [1 -2 -3]", res["model"])
+	}
+	resStats, _ := res["stats"].(map[string]any)
+	if resStats["decisions"] != 2.0 || resStats["propagations"] != 5.0 || resStats["workers"] != 1.0 {
+		t.Errorf("stats %v do not match %+v", res["stats"], stats)
+	}
+
+	// Only satisfiable results have a model
+	out = captureStdout(t, func() {
+		printJSONResult("example.cnf", task, solver.UNSATISFIABLE, nil, time.Second, stats)
+	})
+	res = nil
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("result %q is not JSON: %v", out, err)
+	}
+	if _, ok := res["model"]; ok || res["result"] != "UNSATISFIABLE" {
+		t.Errorf("unsatisfiable result %q", out)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{"100", 100},
+		{"100B", 100},
+		{"4K", 4 << 10},
+		{"4kb", 4 << 10},
+		{"512MB", 512 << 20},
+		{"512m", 512 << 20},
+		{"2GB", 2 << 30},
+		{" 3 G ", 3 << 30},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.size)
+		if err != nil || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", tt.size, got, err, tt.want)
+		}
+	}
+
+	for _, size := range []string{"", "0", "-1MB", "MB", "1.5GB", "10TB", "lots"} {
+		if got, err := parseSize(size); err == nil {
+			t.Errorf("parseSize(%q) = %d, want an error", size, got)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/CptPie/DLPP-solver/logger"
+	dimacsParser "github.com/CptPie/DLPP-solver/parser"
+	"github.com/CptPie/DLPP-solver/solver"
+)
+
+// jsonResult is the result of one input file for --output json
+type jsonResult struct {
+	File       string       `json:"file"`
+	Name       string       `json:"name"`
+	NumVars    int          `json:"numVars"`
+	NumClauses int          `json:"numClauses"`
+	Result     string       `json:"result"`
+	Model      []int        `json:"model,omitempty"` // Every variable as a DIMACS literal, only for SATISFIABLE
+	Time       float64      `json:"timeSeconds"`
+	Stats      solver.Stats `json:"stats"`
+}
+
+// printJSONResult prints the result as a single line JSON object, so folder
+// mode streams JSON Lines
+func printJSONResult(fileName string, task *dimacsParser.Task, result solver.Result, solution *dimacsParser.Clause, elapsed time.Duration, stats solver.Stats) {
+	res := jsonResult{
+		File:       fileName,
+		Name:       task.Name,
+		NumVars:    task.NumVars,
+		NumClauses: len(task.Clauses),
+		Result:     result.String(),
+		Time:       elapsed.Seconds(),
+		Stats:      stats,
+	}
+	if result == solver.SATISFIABLE {
+		res.Model = completeModel(task, solution)
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not encode result: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+// completeModel returns every variable of the task as a DIMACS literal,
+// variables the solution leaves open are set to false
+func completeModel(task *dimacsParser.Task, solution *dimacsParser.Clause) []int {
+	values := make(map[int]bool)
+	for _, v := range solution.Vars {
+		values[v.ID] = !v.Negated
+	}
+	model := make([]int, task.NumVars)
+	for id := 1; id <= task.NumVars; id++ {
+		model[id-1] = id
+		if !values[id] {
+			model[id-1] = -id
+		}
+	}
+	return model
+}
+
+// printCompetitionResult prints the result in SAT competition format: an 's'
+// line with the answer and, for satisfiable problems, 'v' lines assigning
+// every variable. Variables the solution leaves open are set to false.
+func printCompetitionResult(task *dimacsParser.Task, result solver.Result, solution *dimacsParser.Clause) {
+	switch result {
+	case solver.SATISFIABLE:
+		fmt.Println("s SATISFIABLE")
+	case solver.UNSATISFIABLE:
+		fmt.Println("s UNSATISFIABLE")
+		return
+	default:
+		fmt.Println("s UNKNOWN")
+		return
+	}
+
+	line := "v"
+	for _, lit := range completeModel(task, solution) {
+		literal := strconv.Itoa(lit)
+		if len(line)+1+len(literal) > 78 {
+			fmt.Println(line)
+			line = "v"
+		}
+		line += " " + literal
+	}
+	if len(line) > 76 {
+		fmt.Println(line)
+		line = "v"
+	}
+	fmt.Println(line + " 0")
+}
+
+// competitionExitCode returns the exit code SAT competition tools expect
+func competitionExitCode(result solver.Result) int {
+	switch result {
+	case solver.SATISFIABLE:
+		return 10
+	case solver.UNSATISFIABLE:
+		return 20
+	default:
+		return 0
+	}
+}
+
+// logStats prints the counters of a search
+func logStats(stats solver.Stats) {
+	logger.Info("Statistics: %d decisions, %d propagations, %d pure literals, %d failed literals, %d backtracks, %d conflicts, max depth %d, final depth %d\n",
+		stats.Decisions, stats.Propagations, stats.PureLiterals, stats.FailedLiterals, stats.Backtracks, stats.Conflicts, stats.MaxDepth, stats.Depth)
+	logger.Info("Time per phase: propagation %v, solved checks %v, pure literals %v, decisions %v, backtracking %v, analysis %v\n",
+		stats.Time.Propagation, stats.Time.SolvedChecks, stats.Time.PureLiterals, stats.Time.Decisions, stats.Time.Backtracking, stats.Time.Analysis)
+	for id, items := range stats.WorkItems {
+		logger.Info("Worker %d: processed %d work items (%d stolen), created %d, shared %d learned clauses, imported %d\n", id, items.Processed, items.Stolen, items.Created, items.Shared, items.Imported)
+	}
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseModel reads a model from a file, either in SAT competition format
+//
+//	s SATISFIABLE
+//	v 1 -2 3 0
+//
+// where the 'v' lines may be split and end with a 0, or as JSON: either the
+// solver's result object with a "model" list of DIMACS literals, or the
+// encoding of a Clause holding the assigned variables.
+func ParseModel(filepath string) (*Clause, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+
+	content := strings.TrimSpace(string(data))
+	if strings.HasPrefix(content, "{") {
+		var result struct {
+			Vars   []Variable
+			Model  []int  `json:"model"`
+			Result string `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(content), &result); err != nil {
+			return nil, fmt.Errorf("could not parse JSON model: %v", err)
+		}
+		if result.Result != "" && result.Result != "SATISFIABLE" {
+			return nil, fmt.Errorf("the file reports %s instead of a model", result.Result)
+		}
+		model := &Clause{Vars: result.Vars}
+		for _, integer := range result.Model {
+			model.Vars = append(model.Vars, variableFromInt(integer))
+		}
+		return model, nil
+	}
+
+	model := &Clause{Vars: make([]Variable, 0)}
+	found := false
+	for lineNumber, line := range strings.Split(content, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) == 0 || parts[0] != "v" {
+			if len(parts) > 1 && parts[0] == "s" && parts[1] != "SATISFIABLE" {
+				return nil, fmt.Errorf("the file reports %s instead of a model", strings.Join(parts[1:], " "))
+			}
+			continue
+		}
+
+		found = true
+		for _, part := range parts[1:] {
+			integer, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: unexpected token %s, expected integer", lineNumber+1, part)
+			}
+			if integer == 0 {
+				return model, nil
+			}
+			model.Vars = append(model.Vars, variableFromInt(integer))
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no 'v' lines found")
+	}
+	return model, nil
+}
+
+// variableFromInt converts a DIMACS literal to a variable
+func variableFromInt(integer int) Variable {
+	if integer < 0 {
+		return Variable{ID: -integer, Negated: true}
+	}
+	return Variable{ID: integer}
+}
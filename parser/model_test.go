@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeModel stores a model file in a temporary directory and returns its path
+func writeModel(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "model")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseModel(t *testing.T) {
+	want := []Variable{{ID: 1}, {ID: 2, Negated: true}, {ID: 3}}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"competition", "c solved\ns SATISFIABLE\nv 1 -2 3 0\n"},
+		{"split v lines", "s SATISFIABLE\nv 1\nv -2\nv 3 0\n"},
+		{"without s line", "v 1 -2 3 0"},
+		{"without final 0", "v 1 -2\nv 3\n"},
+		{"ignores after 0", "v 1 -2 3 0\nv 4 0\n"},
+		{"result object", `{"result": "SATISFIABLE", "model": [1, -2, 3], "time": 0.1}`},
+		{"clause", `{"Vars": [{"ID": 1}, {"ID": 2, "Negated": true}, {"ID": 3}], "Line": 0}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, err := ParseModel(writeModel(t, tt.content))
+			if err != nil {
+				t.Fatalf("ParseModel: %v", err)
+			}
+			if !slices.Equal(model.Vars, want) {
+				t.Errorf("model %v, want %v", model.Vars, want)
+			}
+		})
+	}
+}
+
+func TestParseModelErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unsatisfiable", "s UNSATISFIABLE\n"},
+		{"unknown", "s UNKNOWN\nv 1 0\n"},
+		{"no v lines", "c nothing here\n"},
+		{"bad literal", "v 1 x 0\n"},
+		{"result without model", `{"result": "UNSATISFIABLE"}`},
+		{"broken JSON", `{"model": [1, -2`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if model, err := ParseModel(writeModel(t, tt.content)); err == nil {
+				t.Errorf("ParseModel accepted %q as %v", tt.content, model.Vars)
+			}
+		})
+	}
+
+	if _, err := ParseModel(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("ParseModel accepted a missing file")
+	}
+}
@@ -37,8 +37,9 @@ import (
 //    - {nbclauses} is the exact number of clauses contained
 
 type Parser struct {
-	FilePath string
-	Lines    []string
+	FilePath    string
+	Lines       []string
+	LineNumbers []int // Line in the file of every entry of Lines, starting at 1
 }
 
 type Task struct {
@@ -50,6 +51,7 @@ type Task struct {
 
 type Clause struct {
 	Vars []Variable
+	Line int // Line of the clause in the input file, 0 if unknown
 }
 
 func (c *Clause) String() string {
@@ -90,28 +92,31 @@ func NewParser(filepath string) (*Parser, error) {
 	defer file.Close()
 
 	var lines []string
+	var lineNumbers []int
 
 	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
 		if scanner.Err() != nil {
 			return nil, fmt.Errorf("failed to read file: %v", scanner.Err())
 		}
 		line := scanner.Text()
 		if line != "" {
 			lines = append(lines, line)
+			lineNumbers = append(lineNumbers, lineNumber)
 		}
 	}
 
 	return &Parser{
-		FilePath: filepath,
-		Lines:    lines,
+		FilePath:    filepath,
+		Lines:       lines,
+		LineNumbers: lineNumbers,
 	}, nil
 }
 
 func (p *Parser) Parse() (*Task, error) {
 	clauses := []*Clause{}
 	task := &Task{}
-	for _, line := range p.Lines {
+	for i, line := range p.Lines {
 		parts := strings.Fields(line)
 
 		// Comment line
@@ -153,6 +158,9 @@ func (p *Parser) Parse() (*Task, error) {
 		if err != nil {
 			return nil, fmt.Errorf("could not parse clause '%s': %v", line, err)
 		}
+		if i < len(p.LineNumbers) {
+			clause.Line = p.LineNumbers[i]
+		}
 
 		clauses = append(clauses, clause)
 
@@ -0,0 +1,337 @@
+package solver
+
+import (
+	"context"
+	"time"
+
+	"github.com/CptPie/DLPP-solver/logger"
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// CDCLSolver implements conflict-driven clause learning. Instead of jumping
+// back to the most recent checkpoint on a contradiction, every conflict is
+// analysed through the implication graph, the resulting 1-UIP clause is
+// learned and the search jumps back to the level where that clause becomes
+// unit.
+type CDCLSolver struct {
+	Result   Result           // Solver result status
+	Problem  *parser.Task     // The problem to solve
+	WorkCopy []*parser.Clause // Clauses left open under the final assignment (useful for UNSAT debugging)
+	Solution *parser.Clause   // The found solution
+	Learned  int              // Number of clauses learned from conflicts
+	Restarts int              // Number of restarts
+	Deleted  int              // Number of learned clauses deleted again by database reductions
+	Proof    *ProofWriter     // Receives the learned and deleted clauses, nil to not write a proof
+	Stats    Stats            // Counters of the last call of Solve
+
+	engine      *propagator        // Watched literal propagation over the original and learned clauses
+	heuristic   BranchingHeuristic // Picks the decision variable
+	restarts    RestartPolicy      // Decides when to abandon the current decisions
+	numOriginal int                // Number of problem clauses, including the ones added between calls
+	learned     *learnedClauses    // LBD, activity and tier of the learned clauses
+	nextReduce  int                // Number of learned clauses at which the database is reduced next, 0 to never reduce
+	reduceStep  int                // Conflicts between the last and the next reduction
+	options     Options            // Budgets of the search
+	levelStamps []int              // Per decision level: last LBD computation that counted it
+	stamp       int
+
+	model  []Lit // Assignment of the last satisfiable call, ordered by variable
+	failed []Lit // Assumptions responsible for the last unsatisfiable call
+	quiet  bool  // Log the start and summary of a call only as steps, e.g. for the many checks of the core extractor
+}
+
+func NewCDCLSolver(task *parser.Task, options Options) *CDCLSolver {
+	return newCDCLSolver(task, NewClauseDB(task), options)
+}
+
+// newCDCLSolver creates a solver of the clauses in the given database, which
+// may hold more than the clauses of the task
+func newCDCLSolver(task *parser.Task, db *ClauseDB, options Options) *CDCLSolver {
+	s := &CDCLSolver{
+		Problem:     task,
+		Result:      UNKNOWN,
+		Solution:    &parser.Clause{},
+		engine:      newPropagator(db),
+		heuristic:   withPolarity(NewHeuristic(options.Heuristic, db.NumVars, options.Seed), options.Polarity),
+		restarts:    NewRestartPolicy(options),
+		numOriginal: db.Len(),
+		learned:     newLearnedClauses(),
+		nextReduce:  max(options.ReduceInterval, 0),
+		reduceStep:  options.ReduceInterval,
+		levelStamps: make([]int, db.NumVars+1),
+		options:     options,
+	}
+	s.engine.attachHeuristic(s.heuristic)
+	return s
+}
+
+// AddClause adds a clause to the problem between calls of Solve. Learned
+// clauses, activities and saved phases are kept, the clause may also use new
+// variables.
+func (s *CDCLSolver) AddClause(lits ...Lit) {
+	s.engine.cancelUntil(0)
+	s.engine.addClause(lits)
+	s.numOriginal++
+}
+
+// Solve searches for an assignment satisfying all clauses in which the given
+// assumptions are true. It can be called repeatedly, every call starts from
+// the clauses learned by the previous ones. The result is UNKNOWN if the
+// context is done or a budget of the options is used up first.
+func (s *CDCLSolver) Solve(ctx context.Context, assumptions ...Lit) {
+	s.logSummary("Starting to solve %d clauses with CDCL.\n", s.numOriginal)
+	if logger.GetLevel() >= logger.FULL {
+		logger.Detail("%s\n", s.engine.openClauses())
+	}
+
+	s.engine.cancelUntil(0)
+	for _, lit := range assumptions {
+		s.engine.reserveVars(lit.Var())
+	}
+	s.Result = UNKNOWN
+	s.model = nil
+	s.failed = nil
+	s.Stats = Stats{Workers: 1}
+	propagations := s.engine.propagations
+
+	for {
+		if cancelled(ctx) || s.options.exhausted(s.Stats.Decisions, s.Stats.Conflicts) {
+			s.logSummary("Search stopped after %d decisions and %d conflicts\n", s.Stats.Decisions, s.Stats.Conflicts)
+			break
+		}
+
+		start := time.Now()
+		conflict := s.engine.propagate()
+		addTime(&s.Stats.Time.Propagation, start)
+		if conflict >= 0 {
+			s.Stats.Conflicts++
+			if s.engine.decisionLevel() == 0 {
+				// A conflict without any decision cannot be resolved
+				logger.Step("Found conflict at decision level 0\n")
+				s.Result = UNSATISFIABLE
+				s.failed = []Lit{}
+				if s.Proof != nil {
+					s.Proof.Add(nil)
+				}
+				break
+			}
+
+			start = time.Now()
+			learnt, backjumpLevel := s.analyze(conflict)
+			lbd := s.literalBlockDistance(learnt)
+			logger.Step("Found conflict at level %d, learned clause %s (LBD %d), backjumping to level %d\n", s.engine.decisionLevel(), ClauseFromLits(learnt), lbd, backjumpLevel)
+
+			s.heuristic.Bump(learnt)
+			addTime(&s.Stats.Time.Analysis, start)
+			start = time.Now()
+			s.engine.cancelUntil(backjumpLevel)
+			addTime(&s.Stats.Time.Backtracking, start)
+			s.Stats.Backtracks++
+			s.Learned++
+
+			// The learned clause is unit after backjumping, its first literal is the asserting one
+			if s.Proof != nil {
+				s.Proof.Add(learnt)
+			}
+			clauseID := s.engine.addLearned(learnt)
+			s.learned.add(clauseID, lbd)
+			if len(learnt) > 1 {
+				s.engine.assign(learnt[0], clauseID)
+			}
+			s.learned.decay()
+
+			// Learned clauses and saved phases survive the restart, only the decisions are dropped
+			if s.restarts.OnConflict(lbd) && s.engine.decisionLevel() > 0 {
+				logger.Step("Restarting after %d learned clauses\n", s.Learned)
+				start = time.Now()
+				s.engine.cancelUntil(0)
+				addTime(&s.Stats.Time.Backtracking, start)
+				s.Restarts++
+			}
+
+			if s.nextReduce > 0 && s.Learned >= s.nextReduce {
+				s.reduceDB()
+			}
+			continue
+		}
+
+		// Assumptions are decided first, each on a level of its own
+		if level := s.engine.decisionLevel(); level < len(assumptions) {
+			assumption := assumptions[level]
+			switch s.engine.Value(assumption) {
+			case 1:
+				// Already implied, the empty level keeps levels and assumptions in step
+				s.engine.newDecisionLevel()
+			case -1:
+				logger.Step("Assumption %s is falsified\n", assumption)
+				s.failed = append(s.engine.failedAssumptions([]Lit{assumption}, assumptions), assumption)
+				s.Result = UNSATISFIABLE
+			default:
+				s.engine.newDecisionLevel()
+				s.engine.assign(assumption, -1)
+				logger.Step("Assumed %s at level %d\n", assumption, s.engine.decisionLevel())
+			}
+			if s.Result == UNSATISFIABLE {
+				break
+			}
+			continue
+		}
+
+		start = time.Now()
+		decision, ok := s.heuristic.PickBranch(s.engine)
+		addTime(&s.Stats.Time.Decisions, start)
+		if !ok {
+			// Every clause is satisfied by the current assignment
+			s.Result = SATISFIABLE
+			s.model = modelFromTrail(s.engine.trail)
+			break
+		}
+
+		s.engine.newDecisionLevel()
+		s.engine.assign(decision, -1)
+		s.Stats.Decisions++
+		s.Stats.MaxDepth = max(s.Stats.MaxDepth, s.engine.decisionLevel())
+		logger.Step("Decided %s at level %d\n", decision, s.engine.decisionLevel())
+	}
+
+	s.Solution = ClauseFromLits(s.engine.trail)
+	s.WorkCopy = s.engine.openClauses()
+	s.Stats.Propagations = s.engine.propagations - propagations
+	s.Stats.Depth = s.engine.decisionLevel()
+
+	s.logSummary("Learned %d clauses (%d deleted again), restarted %d times\n", s.Learned, s.Deleted, s.Restarts)
+}
+
+func (s *CDCLSolver) logSummary(format string, args ...interface{}) {
+	if s.quiet {
+		logger.Step(format, args...)
+	} else {
+		logger.Info(format, args...)
+	}
+}
+
+// LearnedClauses returns copies of the learned clauses currently kept. They
+// follow from the clauses of the problem, adding them to a new solver
+// continues from the knowledge of this one.
+func (s *CDCLSolver) LearnedClauses() [][]Lit {
+	clauses := make([][]Lit, 0)
+	for clauseID := 0; clauseID < s.engine.db.Len(); clauseID++ {
+		if s.engine.db.IsLearned(clauseID) && !s.engine.db.IsDeleted(clauseID) {
+			clauses = append(clauses, append([]Lit{}, s.engine.db.Lits(clauseID)...))
+		}
+	}
+	return clauses
+}
+
+// Model returns the assignment found by the last call of Solve, ordered by
+// variable, or nil if it was not satisfiable
+func (s *CDCLSolver) Model() []Lit {
+	return s.model
+}
+
+// FailedAssumptions returns the assumptions which made the last call of Solve
+// unsatisfiable. It is empty if the clauses are unsatisfiable on their own.
+func (s *CDCLSolver) FailedAssumptions() []Lit {
+	return s.failed
+}
+
+// reduceDB deletes learned clauses of little value and compacts the clause
+// arena, so memory stays bounded on long runs
+func (s *CDCLSolver) reduceDB() {
+	defer addTime(&s.Stats.Time.Analysis, time.Now())
+	deleted := s.learned.reduce(s.engine.db, s.engine.isReason)
+	if s.Proof != nil {
+		for clauseID := 0; clauseID < s.engine.db.Len(); clauseID++ {
+			if s.engine.db.IsDeleted(clauseID) {
+				s.Proof.Delete(s.engine.db.Lits(clauseID))
+			}
+		}
+	}
+	remap := s.engine.db.Compact()
+	s.engine.relocate(remap)
+	s.learned.relocate(remap, s.engine.db.Len())
+	s.Deleted += deleted
+
+	s.reduceStep += reduceIncrement
+	s.nextReduce = s.Learned + s.reduceStep
+	logger.Step("Reduced the clause database by %d learned clauses, %d are left\n", deleted, len(s.learned.clauses))
+}
+
+// literalBlockDistance counts the distinct decision levels among the
+// literals of a clause. Clauses spanning few levels tie decisions closely
+// together and are the most useful ones to learn.
+func (s *CDCLSolver) literalBlockDistance(lits []Lit) int {
+	for len(s.levelStamps) <= s.engine.decisionLevel() {
+		s.levelStamps = append(s.levelStamps, 0)
+	}
+	s.stamp++
+	distance := 0
+	for _, lit := range lits {
+		level := s.engine.levels[lit.Var()]
+		if s.levelStamps[level] != s.stamp {
+			s.levelStamps[level] = s.stamp
+			distance++
+		}
+	}
+	return distance
+}
+
+// analyze derives the first-UIP clause from a conflict by resolving the
+// conflicting clause with the reasons of the current level's literals, walking
+// the trail backwards. It returns the learned clause, with the asserting
+// literal first, together with the level to backjump to.
+func (s *CDCLSolver) analyze(conflict int) ([]Lit, int) {
+	engine := s.engine
+	seen := make([]bool, len(engine.assigns))
+	learnt := []Lit{0} // Position 0 is reserved for the asserting literal
+
+	pending := 0 // Literals of the current level that still need to be resolved
+	reason := conflict
+	index := len(engine.trail) - 1
+	var uip Lit
+
+	for {
+		if engine.db.IsLearned(reason) {
+			s.learned.bump(reason, s.literalBlockDistance(engine.db.Lits(reason)))
+		}
+
+		for _, lit := range engine.db.Lits(reason) {
+			if seen[lit.Var()] || engine.levels[lit.Var()] == 0 {
+				continue
+			}
+			seen[lit.Var()] = true
+			if engine.levels[lit.Var()] == engine.decisionLevel() {
+				pending++
+			} else {
+				learnt = append(learnt, lit)
+			}
+		}
+
+		// Find the most recently assigned literal that is part of the resolvent
+		for !seen[engine.trail[index].Var()] {
+			index--
+		}
+		uip = engine.trail[index]
+		index--
+		pending--
+
+		if pending == 0 {
+			break
+		}
+		reason = engine.reasons[uip.Var()]
+	}
+
+	learnt[0] = uip.Not()
+
+	// Backjump to the highest level among the remaining literals and keep
+	// that literal at position 1 so it is the next one to be unassigned
+	backjumpLevel := 0
+	for i := 1; i < len(learnt); i++ {
+		if engine.levels[learnt[i].Var()] > backjumpLevel {
+			backjumpLevel = engine.levels[learnt[i].Var()]
+			learnt[1], learnt[i] = learnt[i], learnt[1]
+		}
+	}
+
+	return learnt, backjumpLevel
+}
@@ -0,0 +1,96 @@
+package solver
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// examplesPerFolder limits how many of the bundled examples a test solves, so
+// the whole suite stays quick
+const examplesPerFolder = 10
+
+// loadTask parses a DIMACS file
+func loadTask(t *testing.T, path string) *parser.Task {
+	t.Helper()
+	p, err := parser.NewParser(path)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	task, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+	return task
+}
+
+// exampleFiles returns the first examples of a folder below examples/
+func exampleFiles(t *testing.T, folder string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("..", "examples", folder, "*.cnf"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no examples in %s: %v", folder, err)
+	}
+	return files[:min(len(files), examplesPerFolder)]
+}
+
+func TestCDCLSolvesExamples(t *testing.T) {
+	tests := []struct {
+		folder string
+		want   Result
+	}{
+		{"uf20-91", SATISFIABLE},
+		{"uf50-218", SATISFIABLE},
+		{"uuf50-218", UNSATISFIABLE},
+	}
+	for _, tt := range tests {
+		for _, file := range exampleFiles(t, tt.folder) {
+			t.Run(filepath.Base(file), func(t *testing.T) {
+				task := loadTask(t, file)
+				s := NewCDCLSolver(task, DefaultOptions())
+				s.Solve(context.Background())
+				if s.Result != tt.want {
+					t.Fatalf("result %s, want %s", s.Result, tt.want)
+				}
+				if s.Result == SATISFIABLE {
+					if err := Verify(task, s.Solution); err != nil {
+						t.Errorf("solution does not verify: %v", err)
+					}
+					if err := Verify(task, ClauseFromLits(s.Model())); err != nil {
+						t.Errorf("model does not verify: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestCDCLHeuristicsAndRestarts(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uf50-218")[0])
+	unsat := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+	for heuristic := DLCS; heuristic <= VSIDS; heuristic++ {
+		for restart := NoRestarts; restart <= GlucoseRestarts; restart++ {
+			options := DefaultOptions()
+			options.Heuristic = heuristic
+			options.Restart = restart
+			options.RestartInterval = 2
+			options.ReduceInterval = 20
+
+			s := NewCDCLSolver(task, options)
+			s.Solve(context.Background())
+			if s.Result != SATISFIABLE {
+				t.Errorf("%s/%s: result %s on a satisfiable task", heuristic, restart, s.Result)
+			} else if err := Verify(task, s.Solution); err != nil {
+				t.Errorf("%s/%s: solution does not verify: %v", heuristic, restart, err)
+			}
+
+			s = NewCDCLSolver(unsat, options)
+			s.Solve(context.Background())
+			if s.Result != UNSATISFIABLE {
+				t.Errorf("%s/%s: result %s on an unsatisfiable task", heuristic, restart, s.Result)
+			}
+		}
+	}
+}
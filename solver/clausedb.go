@@ -0,0 +1,160 @@
+package solver
+
+import (
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// ClauseDB stores clauses in one flat arena of literals. A clause is
+// referenced by its index, which points to the start and size of its
+// literals in the arena. The solver state (assignments, watches) is kept
+// outside of it, so the parsed task is never modified.
+type ClauseDB struct {
+	NumVars int // Highest variable ID used by any clause
+
+	lits    []Lit          // Literals of all clauses, back to back
+	headers []clauseHeader // Per clause: position of its literals in the arena
+}
+
+type clauseHeader struct {
+	start   uint32
+	size    uint32
+	deleted bool
+	learned bool
+}
+
+// NewClauseDB converts the clauses of a parsed task to the packed representation.
+// Duplicate literals inside a clause are dropped.
+func NewClauseDB(task *parser.Task) *ClauseDB {
+	size := 0
+	for _, clause := range task.Clauses {
+		size += len(clause.Vars)
+	}
+
+	db := &ClauseDB{
+		NumVars: task.NumVars,
+		lits:    make([]Lit, 0, size),
+		headers: make([]clauseHeader, 0, len(task.Clauses)),
+	}
+
+	lits := make([]Lit, 0)
+	for _, clause := range task.Clauses {
+		lits = lits[:0]
+		for _, cVar := range clause.Vars {
+			lits = append(lits, LitFromVariable(cVar))
+		}
+		db.Add(lits)
+	}
+
+	return db
+}
+
+// Add appends a clause of the problem to the arena and returns its index
+func (db *ClauseDB) Add(lits []Lit) int {
+	return db.add(lits, false)
+}
+
+// AddLearned appends a clause derived by the solver and returns its index
+func (db *ClauseDB) AddLearned(lits []Lit) int {
+	return db.add(lits, true)
+}
+
+func (db *ClauseDB) add(lits []Lit, learned bool) int {
+	start := len(db.lits)
+	for _, lit := range lits {
+		duplicate := false
+		for _, other := range db.lits[start:] {
+			if other == lit {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			db.lits = append(db.lits, lit)
+		}
+		if lit.Var() > db.NumVars {
+			db.NumVars = lit.Var()
+		}
+	}
+
+	db.headers = append(db.headers, clauseHeader{
+		start:   uint32(start),
+		size:    uint32(len(db.lits) - start),
+		learned: learned,
+	})
+	return len(db.headers) - 1
+}
+
+// Lits returns the literals of a clause. The slice points into the arena, so
+// reordering it reorders the stored clause.
+func (db *ClauseDB) Lits(clauseID int) []Lit {
+	header := db.headers[clauseID]
+	return db.lits[header.start : header.start+header.size]
+}
+
+// Len returns the number of clauses
+func (db *ClauseDB) Len() int {
+	return len(db.headers)
+}
+
+// Delete marks a clause as deleted. Its literals stay in the arena until the
+// next Compact, so the clause must not be referenced after that anymore.
+func (db *ClauseDB) Delete(clauseID int) {
+	db.headers[clauseID].deleted = true
+}
+
+func (db *ClauseDB) IsDeleted(clauseID int) bool {
+	return db.headers[clauseID].deleted
+}
+
+// IsLearned reports if the clause was derived by the solver instead of being part of the problem
+func (db *ClauseDB) IsLearned(clauseID int) bool {
+	return db.headers[clauseID].learned
+}
+
+// Compact drops the deleted clauses from the arena. The remaining clauses keep
+// their order and literal order, but get new indices; the returned slice maps
+// every old index to the new one, or -1 for deleted clauses.
+func (db *ClauseDB) Compact() []int {
+	remap := make([]int, len(db.headers))
+	lits := db.lits[:0]
+	headers := db.headers[:0]
+
+	for clauseID, header := range db.headers {
+		if header.deleted {
+			remap[clauseID] = -1
+			continue
+		}
+		// The arena is rewritten front to back, so a clause never overwrites literals still to be copied
+		start := len(lits)
+		lits = append(lits, db.lits[header.start:header.start+header.size]...)
+		remap[clauseID] = len(headers)
+		headers = append(headers, clauseHeader{start: uint32(start), size: header.size, learned: header.learned})
+	}
+
+	db.lits = lits
+	db.headers = headers
+	return remap
+}
+
+// Clone returns an independent copy, e.g. for a worker that reorders literals
+// while watching them
+func (db *ClauseDB) Clone() *ClauseDB {
+	clone := &ClauseDB{
+		NumVars: db.NumVars,
+		lits:    make([]Lit, len(db.lits)),
+		headers: make([]clauseHeader, len(db.headers)),
+	}
+	copy(clone.lits, db.lits)
+	copy(clone.headers, db.headers)
+	return clone
+}
+
+// memory estimates the bytes held by the arena and the clause headers
+func (db *ClauseDB) memory() int64 {
+	return int64(cap(db.lits))*litBytes + int64(cap(db.headers))*clauseHeaderSize
+}
+
+// Clause converts a stored clause back to parsed variables
+func (db *ClauseDB) Clause(clauseID int) *parser.Clause {
+	return ClauseFromLits(db.Lits(clauseID))
+}
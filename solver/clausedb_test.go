@@ -0,0 +1,101 @@
+package solver
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+func TestLit(t *testing.T) {
+	for _, v := range []parser.Variable{{ID: 1}, {ID: 7, Negated: true}, {ID: 1 << 20}} {
+		lit := LitFromVariable(v)
+		if lit.Var() != v.ID || lit.Negated() != v.Negated {
+			t.Errorf("%v packed as variable %d, negated %v", v, lit.Var(), lit.Negated())
+		}
+		if lit.Variable() != v {
+			t.Errorf("%v unpacked as %v", v, lit.Variable())
+		}
+		if not := lit.Not(); not.Var() != v.ID || not.Negated() == v.Negated || not.Not() != lit {
+			t.Errorf("negation of %s is %s", lit, not)
+		}
+	}
+	if s := MkLit(3, true).String(); s != "-3" {
+		t.Errorf("-3 printed as %q", s)
+	}
+	if s := ClauseFromLits([]Lit{MkLit(1, false), MkLit(2, true)}).String(); s != "{ 1 -2 }" {
+		t.Errorf("clause printed as %q", s)
+	}
+}
+
+func TestClauseDB(t *testing.T) {
+	task := taskFromLits(3, [][]Lit{{pos(1), neg(2), pos(1)}, {neg(3)}, {}})
+	db := NewClauseDB(task)
+
+	if db.Len() != 3 {
+		t.Fatalf("%d clauses, want 3", db.Len())
+	}
+	for clauseID, want := range [][]Lit{{pos(1), neg(2)}, {neg(3)}, {}} {
+		if lits := db.Lits(clauseID); !slices.Equal(lits, want) {
+			t.Errorf("clause %d stored as %v, want %v", clauseID, lits, want)
+		}
+	}
+	if task.Clauses[0].Vars[2] != pos(1).Variable() {
+		t.Error("the parsed task was modified")
+	}
+
+	// Added clauses may use new variables
+	if clauseID := db.Add([]Lit{neg(5), neg(5), pos(4)}); clauseID != 3 || !slices.Equal(db.Lits(clauseID), []Lit{neg(5), pos(4)}) {
+		t.Errorf("added clause %d stored as %v", clauseID, db.Lits(clauseID))
+	}
+	if db.NumVars != 5 {
+		t.Errorf("%d variables after adding variable 5", db.NumVars)
+	}
+
+	// Reordering the literals of a clone leaves the original alone
+	clone := db.Clone()
+	lits := clone.Lits(0)
+	lits[0], lits[1] = lits[1], lits[0]
+	if !slices.Equal(db.Lits(0), []Lit{pos(1), neg(2)}) {
+		t.Errorf("reordering the clone changed the original to %v", db.Lits(0))
+	}
+	clone.Add([]Lit{pos(2)})
+	if db.Len() != 4 {
+		t.Errorf("adding to the clone added to the original")
+	}
+}
+
+func TestClauseDBCompact(t *testing.T) {
+	task := taskFromLits(6, [][]Lit{{pos(1), pos(2)}, {neg(2), pos(3)}, {pos(4)}, {neg(4), neg(5), pos(6)}, {neg(6), pos(1)}})
+	db := NewClauseDB(task)
+	db.Delete(1)
+	db.Delete(3)
+	if !db.IsDeleted(1) || db.IsDeleted(2) {
+		t.Fatal("deleted clauses not marked")
+	}
+	// Deleted clauses keep their literals until the database is compacted
+	if lits := db.Lits(3); len(lits) != 3 {
+		t.Errorf("deleted clause lost its literals before compacting: %v", lits)
+	}
+
+	remap := db.Compact()
+	if want := []int{0, -1, 1, -1, 2}; !slices.Equal(remap, want) {
+		t.Fatalf("remap %v, want %v", remap, want)
+	}
+	if db.Len() != 3 {
+		t.Errorf("%d clauses after compacting, want 3", db.Len())
+	}
+	for oldID, newID := range remap {
+		if newID < 0 {
+			continue
+		}
+		if want := LitFromVariable(task.Clauses[oldID].Vars[0]); db.Lits(newID)[0] != want || db.IsDeleted(newID) {
+			t.Errorf("clause %d moved to %d as %v", oldID, newID, db.Lits(newID))
+		}
+	}
+
+	// Clauses added afterwards go behind the kept ones
+	if clauseID := db.Add([]Lit{pos(5)}); clauseID != 3 || !slices.Equal(db.Lits(2), []Lit{neg(6), pos(1)}) {
+		t.Errorf("clause added as %d, clause 2 is now %v", clauseID, db.Lits(2))
+	}
+}
@@ -0,0 +1,110 @@
+package solver
+
+import (
+	"context"
+	"sort"
+
+	"github.com/CptPie/DLPP-solver/logger"
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// CoreExtractor finds subsets of the clauses of a task that are unsatisfiable
+// on their own. Every clause gets a selector variable which switches it off
+// when false, so a single incremental CDCL solver can check any subset of the
+// clauses by assuming the selectors of the subset. The failed assumptions of
+// an unsatisfiable check are the clauses it depended on.
+type CoreExtractor struct {
+	Task   *parser.Task
+	Checks int // Number of subsets checked so far
+
+	solver    *CDCLSolver
+	selectors []Lit // Per clause of the task: literal that enables it
+}
+
+func NewCoreExtractor(task *parser.Task, options Options) *CoreExtractor {
+	ce := &CoreExtractor{
+		Task:      task,
+		solver:    NewCDCLSolver(&parser.Task{Name: task.Name, NumVars: task.NumVars}, options),
+		selectors: make([]Lit, len(task.Clauses)),
+	}
+	ce.solver.quiet = true
+
+	lits := make([]Lit, 0)
+	for i, clause := range task.Clauses {
+		ce.selectors[i] = MkLit(task.NumVars+1+i, false)
+		lits = lits[:0]
+		for _, cVar := range clause.Vars {
+			lits = append(lits, LitFromVariable(cVar))
+		}
+		lits = append(lits, ce.selectors[i].Not())
+		ce.solver.AddClause(lits...)
+	}
+
+	return ce
+}
+
+// Core checks all clauses of the task and returns the indices of an
+// unsatisfiable subset of them, ordered by index. The result is SATISFIABLE
+// and the core empty if the task has a solution, UNKNOWN if the context was
+// done or the budgets of the options ran out first.
+func (ce *CoreExtractor) Core(ctx context.Context) (Result, []int) {
+	all := make([]int, len(ce.Task.Clauses))
+	for i := range all {
+		all[i] = i
+	}
+	return ce.check(ctx, all)
+}
+
+// Minimize shrinks an unsatisfiable core until removing any single clause
+// makes it satisfiable, i.e. to a minimal unsatisfiable subset (MUS). Clauses
+// are tried one after another; if the core stays unsatisfiable without one,
+// the clauses the check depended on become the new core. A check that gives
+// up stops the minimization, the core is returned as far as it got and false.
+func (ce *CoreExtractor) Minimize(ctx context.Context, core []int) ([]int, bool) {
+	core = append([]int{}, core...)
+	candidate := make([]int, 0, len(core))
+
+	for i := 0; i < len(core); {
+		candidate = append(append(candidate[:0], core[:i]...), core[i+1:]...)
+		result, smaller := ce.check(ctx, candidate)
+		if result == UNKNOWN {
+			logger.Step("Check without clause %d gave up, core has %d clauses\n", core[i], len(core))
+			return core, false
+		}
+		if result != UNSATISFIABLE {
+			// Every core needs this clause, keep it and try the next one
+			logger.Step("Clause %d is needed, core has %d clauses\n", core[i], len(core))
+			i++
+			continue
+		}
+
+		// The needed clauses before i are part of every smaller core as well,
+		// so they keep their positions in the sorted result
+		logger.Step("Clause %d is not needed, core has %d clauses\n", core[i], len(smaller))
+		core = smaller
+	}
+
+	return core, true
+}
+
+// check solves the task restricted to the given clauses and returns the
+// clauses the result depended on if it is unsatisfiable
+func (ce *CoreExtractor) check(ctx context.Context, clauses []int) (Result, []int) {
+	ce.Checks++
+
+	assumptions := make([]Lit, len(clauses))
+	for i, clauseID := range clauses {
+		assumptions[i] = ce.selectors[clauseID]
+	}
+	ce.solver.Solve(ctx, assumptions...)
+	if ce.solver.Result != UNSATISFIABLE {
+		return ce.solver.Result, []int{}
+	}
+
+	core := make([]int, 0)
+	for _, lit := range ce.solver.FailedAssumptions() {
+		core = append(core, lit.Var()-ce.Task.NumVars-1)
+	}
+	sort.Ints(core)
+	return UNSATISFIABLE, core
+}
@@ -0,0 +1,158 @@
+package solver
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// subTask returns a task with only the given clauses of another one
+func subTask(task *parser.Task, clauses []int) *parser.Task {
+	sub := &parser.Task{Name: task.Name, NumVars: task.NumVars}
+	for _, i := range clauses {
+		sub.Clauses = append(sub.Clauses, task.Clauses[i])
+	}
+	return sub
+}
+
+// solveWithCDCL returns the result of a fresh CDCL solver on a task
+func solveWithCDCL(task *parser.Task) Result {
+	s := NewCDCLSolver(task, DefaultOptions())
+	s.Solve(context.Background())
+	return s.Result
+}
+
+// checkMUS makes sure the clauses are unsatisfiable, but no longer are if any
+// one of them is left out
+func checkMUS(t *testing.T, task *parser.Task, mus []int) {
+	t.Helper()
+	if result := solveWithCDCL(subTask(task, mus)); result != UNSATISFIABLE {
+		t.Fatalf("MUS %v is %s", mus, result)
+	}
+	for i := range mus {
+		rest := slices.Delete(slices.Clone(mus), i, i+1)
+		if result := solveWithCDCL(subTask(task, rest)); result != SATISFIABLE {
+			t.Errorf("MUS %v without clause %d is %s, so it is not minimal", mus, mus[i], result)
+		}
+	}
+}
+
+func TestMinimalUnsatisfiableSubset(t *testing.T) {
+	// Clauses 0, 2 and 4 are the only unsatisfiable subset, 5 does not even
+	// share a variable with the others
+	task := taskFromLits(4, [][]Lit{
+		{pos(1)},
+		{pos(1), pos(2)},
+		{neg(1), pos(3)},
+		{neg(2), neg(3)},
+		{neg(3)},
+		{pos(4)},
+	})
+	ce := NewCoreExtractor(task, DefaultOptions())
+
+	result, core := ce.Core(context.Background())
+	if result != UNSATISFIABLE {
+		t.Fatalf("result %s, want %s", result, UNSATISFIABLE)
+	}
+	if slices.Contains(core, 5) {
+		t.Errorf("core %v contains the unrelated clause 5", core)
+	}
+	if solveWithCDCL(subTask(task, core)) != UNSATISFIABLE {
+		t.Errorf("core %v is satisfiable", core)
+	}
+
+	mus, minimal := ce.Minimize(context.Background(), core)
+	if !minimal {
+		t.Fatal("minimization stopped early")
+	}
+	if want := []int{0, 2, 4}; !slices.Equal(mus, want) {
+		t.Errorf("MUS %v, want %v", mus, want)
+	}
+	checkMUS(t, task, mus)
+}
+
+func TestMinimalUnsatisfiableSubsetsOfRandomFormulas(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for found := 0; found < 20; {
+		numVars := 4 + r.Intn(4)
+		clauses := make([][]Lit, 0)
+		for i := 0; i < 6*numVars; i++ {
+			clauses = append(clauses, randomClause(r, numVars, 1+r.Intn(3)))
+		}
+		if bruteForce(numVars, clauses, nil) {
+			continue
+		}
+		found++
+
+		ce := NewCoreExtractor(taskFromLits(numVars, clauses), DefaultOptions())
+		result, core := ce.Core(context.Background())
+		if result != UNSATISFIABLE {
+			t.Fatalf("result %s on %v", result, clauses)
+		}
+		mus, _ := ce.Minimize(context.Background(), core)
+
+		sub := make([][]Lit, len(mus))
+		for i, clauseID := range mus {
+			sub[i] = clauses[clauseID]
+		}
+		if bruteForce(numVars, sub, nil) {
+			t.Fatalf("MUS %v of %v is satisfiable", mus, clauses)
+		}
+		for i := range sub {
+			if !bruteForce(numVars, slices.Delete(slices.Clone(sub), i, i+1), nil) {
+				t.Fatalf("MUS %v of %v stays unsatisfiable without clause %d", mus, clauses, mus[i])
+			}
+		}
+	}
+}
+
+func TestCoreOfExample(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+	ce := NewCoreExtractor(task, DefaultOptions())
+
+	result, core := ce.Core(context.Background())
+	if result != UNSATISFIABLE {
+		t.Fatalf("result %s, want %s", result, UNSATISFIABLE)
+	}
+	mus, minimal := ce.Minimize(context.Background(), core)
+	if !minimal {
+		t.Fatal("minimization stopped early")
+	}
+	if len(mus) > len(core) {
+		t.Errorf("MUS has %d clauses, more than the core with %d", len(mus), len(core))
+	}
+	checkMUS(t, task, mus)
+}
+
+func TestCoreOfSatisfiableTask(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uf20-91")[0])
+	result, core := NewCoreExtractor(task, DefaultOptions()).Core(context.Background())
+	if result != SATISFIABLE || len(core) != 0 {
+		t.Errorf("result %s with core %v, want %s without core", result, core, SATISFIABLE)
+	}
+}
+
+func TestCoreExtractionStopsOnCancelledContext(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+	ce := NewCoreExtractor(task, DefaultOptions())
+	result, core := ce.Core(context.Background())
+	if result != UNSATISFIABLE {
+		t.Fatalf("result %s, want %s", result, UNSATISFIABLE)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result, _ := ce.Core(ctx); result != UNKNOWN {
+		t.Errorf("Core on a cancelled context: result %s, want %s", result, UNKNOWN)
+	}
+	mus, minimal := ce.Minimize(ctx, core)
+	if minimal {
+		t.Error("minimization on a cancelled context reported a minimal core")
+	}
+	if !slices.Equal(mus, core) {
+		t.Errorf("minimization on a cancelled context returned %v instead of the core", mus)
+	}
+}
@@ -0,0 +1,135 @@
+package solver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/CptPie/DLPP-solver/logger"
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// Cuber splits a problem into cubes for cube-and-conquer. The search tree is
+// built breadth first, every node splits on the variable its lookahead scores
+// best, until the cubes reach the maximum depth or number. Nodes refuted by
+// the lookahead are dropped, so the problem is satisfiable exactly if one of
+// the cubes is.
+type Cuber struct {
+	Problem  *parser.Task
+	MaxDepth int // Most splits per cube, 0 for no limit
+	MaxCubes int // Most cubes, 0 for no limit
+	Refuted  int // Nodes refuted by the lookahead during the last call of Cubes
+	Solved   int // Cubes of the last call of Cubes that satisfy every clause
+
+	engine *propagator
+	look   *lookahead
+}
+
+// cubeNode is a node of the cube tree that is not split yet
+type cubeNode struct {
+	lits  []Lit
+	depth int
+}
+
+func NewCuber(task *parser.Task, maxDepth int, maxCubes int) *Cuber {
+	engine := newPropagator(NewClauseDB(task))
+	return &Cuber{
+		Problem:  task,
+		MaxDepth: maxDepth,
+		MaxCubes: maxCubes,
+		engine:   engine,
+		look:     newLookahead(engine, lookaheadCandidates),
+	}
+}
+
+// AddClause adds a clause to the problem between calls of Cubes
+func (c *Cuber) AddClause(lits ...Lit) {
+	c.engine.cancelUntil(0)
+	c.engine.addClause(lits)
+}
+
+// Cubes splits the assignments in which the given assumptions are true into
+// cubes. The assumptions are not part of the cubes. Nodes left once the
+// context is done become cubes as they are.
+func (c *Cuber) Cubes(ctx context.Context, assumptions ...Lit) [][]Lit {
+	c.Refuted, c.Solved = 0, 0
+	failed := c.look.FailedLiterals
+
+	cubes := make([][]Lit, 0)
+	queue := []cubeNode{{lits: []Lit{}}}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		// Splitting the node replaces one cube by two
+		full := (c.MaxDepth > 0 && node.depth >= c.MaxDepth) || (c.MaxCubes > 0 && len(cubes)+len(queue)+2 > c.MaxCubes)
+		if full || cancelled(ctx) {
+			cubes = append(cubes, node.lits)
+			continue
+		}
+
+		branch, implied, outcome := c.expand(assumptions, node.lits)
+		lits := append(append([]Lit{}, node.lits...), implied...)
+		switch outcome {
+		case lookConflict:
+			logger.Step("Cube %s refuted by lookahead\n", ClauseFromLits(lits))
+			c.Refuted++
+		case lookSolved:
+			c.Solved++
+			cubes = append(cubes, lits)
+		default:
+			logger.Step("Splitting cube %s on %s\n", ClauseFromLits(lits), branch)
+			queue = append(queue,
+				cubeNode{lits: append(append([]Lit{}, lits...), branch), depth: node.depth + 1},
+				cubeNode{lits: append(lits, branch.Not()), depth: node.depth + 1})
+		}
+	}
+	c.engine.cancelUntil(0)
+
+	logger.Info("Split the problem into %d cubes with lookahead, %d refuted, %d failed literals\n", len(cubes), c.Refuted, c.look.FailedLiterals-failed)
+	return cubes
+}
+
+// expand assigns the assumptions and the literals of a node on the first
+// decision level and looks ahead from there
+func (c *Cuber) expand(assumptions []Lit, lits []Lit) (Lit, []Lit, lookOutcome) {
+	c.engine.cancelUntil(0)
+	c.engine.newDecisionLevel()
+	for _, cube := range [][]Lit{assumptions, lits} {
+		for _, lit := range cube {
+			c.engine.reserveVars(lit.Var())
+			switch c.engine.Value(lit) {
+			case 0:
+				c.engine.assign(lit, -1)
+			case -1:
+				return 0, nil, lookConflict
+			}
+		}
+	}
+	if c.engine.propagate() >= 0 {
+		return 0, nil, lookConflict
+	}
+	return c.look.look()
+}
+
+// WriteICNF writes the clauses of a task followed by one cube per line in the
+// incremental CNF format, so the cubes can be solved elsewhere
+func WriteICNF(w io.Writer, task *parser.Task, cubes [][]Lit) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "p inccnf")
+	for _, clause := range task.Clauses {
+		for _, cVar := range clause.Vars {
+			fmt.Fprintf(out, "%s ", LitFromVariable(cVar))
+		}
+		fmt.Fprintln(out, "0")
+	}
+	for _, cube := range cubes {
+		fmt.Fprint(out, "a ")
+		for _, lit := range cube {
+			fmt.Fprintf(out, "%s ", lit)
+		}
+		fmt.Fprintln(out, "0")
+	}
+	return out.Flush()
+}
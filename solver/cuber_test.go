@@ -0,0 +1,119 @@
+package solver
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestCubesOfExamples(t *testing.T) {
+	tests := []struct {
+		folder string
+		want   Result
+	}{
+		{"uf50-218", SATISFIABLE},
+		{"uuf50-218", UNSATISFIABLE},
+	}
+	for _, tt := range tests {
+		for _, file := range exampleFiles(t, tt.folder) {
+			task := loadTask(t, file)
+			cuber := NewCuber(task, 4, 10)
+			cubes := cuber.Cubes(context.Background())
+			if len(cubes) > cuber.MaxCubes {
+				t.Errorf("%s: %d cubes, more than %d", filepath.Base(file), len(cubes), cuber.MaxCubes)
+			}
+
+			// The problem is satisfiable exactly if one of the cubes is
+			s := NewCDCLSolver(task, DefaultOptions())
+			result := UNSATISFIABLE
+			for _, cube := range cubes {
+				s.Solve(context.Background(), cube...)
+				if s.Result == SATISFIABLE {
+					result = SATISFIABLE
+					if err := Verify(task, ClauseFromLits(s.Model())); err != nil {
+						t.Errorf("%s: model of cube %v rejected: %v", filepath.Base(file), cube, err)
+					}
+				}
+			}
+			if result != tt.want {
+				t.Errorf("%s: cubes are %s, want %s", filepath.Base(file), result, tt.want)
+			}
+		}
+	}
+}
+
+func TestCubesWithinLimits(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+	for _, limits := range [][2]int{{1, 0}, {2, 0}, {0, 3}, {0, 16}, {3, 5}} {
+		cuber := NewCuber(task, limits[0], limits[1])
+		cubes := cuber.Cubes(context.Background())
+		if cuber.MaxCubes > 0 && len(cubes) > cuber.MaxCubes {
+			t.Errorf("depth %d, cubes %d: got %d cubes", limits[0], limits[1], len(cubes))
+		}
+		if cuber.MaxDepth > 0 && len(cubes)+cuber.Refuted > 1<<cuber.MaxDepth {
+			t.Errorf("depth %d, cubes %d: %d cubes and %d refuted nodes need more splits", limits[0], limits[1], len(cubes), cuber.Refuted)
+		}
+	}
+}
+
+func TestCubesUnderAssumptions(t *testing.T) {
+	// 1 and 2 imply 3, which contradicts 4
+	task := taskFromLits(5, [][]Lit{{neg(1), neg(2), pos(3)}, {neg(3), neg(4)}, {pos(4), pos(5)}})
+	cuber := NewCuber(task, 3, 0)
+	if cubes := cuber.Cubes(context.Background(), pos(1), pos(2), pos(4)); len(cubes) != 0 {
+		t.Errorf("cubes %v under contradicting assumptions", cubes)
+	}
+
+	cubes := cuber.Cubes(context.Background(), pos(1), pos(2))
+	if len(cubes) == 0 {
+		t.Fatal("no cubes under satisfiable assumptions")
+	}
+	for _, cube := range cubes {
+		for _, lit := range cube {
+			if lit == pos(1) || lit == pos(2) {
+				t.Errorf("cube %v contains an assumption", cube)
+			}
+		}
+	}
+
+	// Clauses added between calls are taken into account
+	cuber.AddClause(neg(1), neg(2))
+	if cubes := cuber.Cubes(context.Background(), pos(1), pos(2)); len(cubes) != 0 {
+		t.Errorf("cubes %v after the assumptions were ruled out", cubes)
+	}
+}
+
+func TestCubeAndConquer(t *testing.T) {
+	for _, folder := range []string{"uf50-218", "uuf50-218"} {
+		task := loadTask(t, exampleFiles(t, folder)[0])
+		want := solveWithCDCL(task)
+		cubes := NewCuber(task, 3, 8).Cubes(context.Background())
+		for _, backend := range []Algorithm{DPLL, CDCL, LOOKAHEAD} {
+			ps := NewParallelSolver(task, 4, 0, false, DefaultOptions())
+			ps.Cubes = cubes
+			ps.Backend = backend
+			result, solution := ps.Solve(context.Background())
+			if result != want {
+				t.Errorf("%s with %s: result %s, want %s", folder, backend, result, want)
+			}
+			if result == SATISFIABLE {
+				if err := Verify(task, solution); err != nil {
+					t.Errorf("%s with %s: solution rejected: %v", folder, backend, err)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteICNF(t *testing.T) {
+	task := taskFromLits(3, [][]Lit{{pos(1), neg(2)}, {pos(3)}})
+	var out bytes.Buffer
+	if err := WriteICNF(&out, task, [][]Lit{{pos(1)}, {neg(1), pos(2)}, {}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "p inccnf\n1 -2 0\n3 0\na 1 0\na -1 2 0\na 0\n"
+	if out.String() != want {
+		t.Errorf("wrote %q, want %q", out.String(), want)
+	}
+}
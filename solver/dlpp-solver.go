@@ -1,6 +1,10 @@
 package solver
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/CptPie/DLPP-solver/logger"
 	"github.com/CptPie/DLPP-solver/parser"
 	"github.com/CptPie/DLPP-solver/utils"
@@ -19,17 +23,68 @@ func (r Result) String() string {
 	return [...]string{"UNSOLVED", "SATISFIABLE", "UNSATISFIABLE", "UNKNOWN"}[r]
 }
 
+type Algorithm int
+
+const (
+	DPLL      Algorithm = iota // Classic DPLL with chronological backtracking
+	CDCL                       // Conflict-driven clause learning with non-chronological backjumping
+	LOOKAHEAD                  // DPLL splitting on the variable a lookahead scores best, with failed literal detection
+)
+
+func (a Algorithm) String() string {
+	return [...]string{"dpll", "cdcl", "lookahead"}[a]
+}
+
+// ParseAlgorithm converts a string to an Algorithm
+func ParseAlgorithm(algorithmStr string) (Algorithm, error) {
+	switch algorithmStr {
+	case "dpll":
+		return DPLL, nil
+	case "cdcl":
+		return CDCL, nil
+	case "lookahead":
+		return LOOKAHEAD, nil
+	default:
+		return DPLL, fmt.Errorf("unknown algorithm '%s', expected 'dpll', 'cdcl' or 'lookahead'", algorithmStr)
+	}
+}
+
 type Solver struct {
 	Result          Result           // Solver result status
 	Problem         *parser.Task     // The problem to solve
-	WorkCopy        []*parser.Clause // Working copy of the clauses (used for reducing)
+	WorkCopy        []*parser.Clause // Clauses left open under the final assignment (useful for UNSAT debugging)
 	Solution        *parser.Clause   // The found solution
 	CheckpointStack *CheckpointStack // Stack for storing checkpoints for backtracking
+	Proof           *ProofWriter     // Receives the clauses implied by backtracking, nil to not write a proof
+	Stats           Stats            // Counters of the last call of Solve
+
+	engine    *propagator        // Watched literal propagation over the clauses
+	heuristic BranchingHeuristic // Picks the variable to split on
+	look      *lookahead         // Picks the variable to split on instead of the heuristic, nil to use the heuristic
+	options   Options            // Budgets of the search
+
+	model  []Lit // Assignment of the last satisfiable call, ordered by variable
+	failed []Lit // Assumptions responsible for the last unsatisfiable call
+
+	lemmas  []proofLemma     // Clauses added to the proof which are still needed
+	learn   func(lits []Lit) // Receives the clause implied by every refuted split, nil if nobody needs them
+	partial bool             // Parts of the search were left open, so running out of checkpoints refutes nothing
+}
+
+// proofLemma is a clause written to the proof, together with the decision
+// level its last literal was assigned at
+type proofLemma struct {
+	level int
+	lits  []Lit
 }
 
+// Checkpoint marks an open split on the assignment trail. Only the decision
+// level the split was made at is stored: the split variable is the first
+// assignment of the following level, so backtracking undoes the trail down to
+// this level and assigns the opposite state.
 type Checkpoint struct {
-	WorkCopy []*parser.Clause
-	Solution *parser.Clause
+	Level   int
+	Partial bool // The first branch was not searched to the end, backtracking does not refute it
 }
 
 type CheckpointStack struct {
@@ -52,422 +107,373 @@ func (cs *CheckpointStack) Pop() *Checkpoint {
 	return res
 }
 
-func NewSolver(task *parser.Task) *Solver {
-	sol := &parser.Clause{}
-
-	// Create a deep copy of the task's clauses for WorkCopy
-	// to avoid modifying the original task data
-	workCopy := make([]*parser.Clause, len(task.Clauses))
-	for i, clause := range task.Clauses {
-		clauseCopy := &parser.Clause{
-			Vars: make([]parser.Variable, len(clause.Vars)),
-		}
-		copy(clauseCopy.Vars, clause.Vars)
-		workCopy[i] = clauseCopy
-	}
-
-	return &Solver{
+func NewSolver(task *parser.Task, options Options) *Solver {
+	db := NewClauseDB(task)
+	s := &Solver{
 		Problem:         task,
-		WorkCopy:        workCopy,
 		Result:          UNKNOWN,
-		Solution:        sol,
+		Solution:        &parser.Clause{},
 		CheckpointStack: &CheckpointStack{},
+		engine:          newPropagator(db),
+		heuristic:       withPolarity(NewHeuristic(options.Heuristic, db.NumVars, options.Seed), options.Polarity),
+		options:         options,
 	}
+	s.engine.attachHeuristic(s.heuristic)
+	return s
 }
 
-func (s *Solver) markCheckpoint() *Checkpoint {
-	// Deep copy the WorkCopy - must copy the clauses themselves, not just the slice of pointers
-	wc := make([]*parser.Clause, len(s.WorkCopy))
-	for i, clause := range s.WorkCopy {
-		// Create a new clause
-		clauseCopy := &parser.Clause{
-			Vars: make([]parser.Variable, len(clause.Vars)),
+// NewLookaheadSolver creates a DPLL solver that splits on the variable a
+// lookahead scores best instead of asking the branching heuristic, and
+// assigns the negations of failed literals it finds on the way
+func NewLookaheadSolver(task *parser.Task, options Options) *Solver {
+	s := NewSolver(task, options)
+	s.look = newLookahead(s.engine, lookaheadCandidates)
+	s.look.onForce = func(lit Lit) {
+		if s.Proof != nil {
+			// The failed literal propagates to a conflict under the assignments so far
+			s.addLemma(s.engine.decisionLevel(), lit, s.engine.trail)
 		}
-		// Deep copy all variables in the clause
-		copy(clauseCopy.Vars, clause.Vars)
-		wc[i] = clauseCopy
 	}
+	return s
+}
 
-	solutionCopy := parser.Clause{}
-	for _, cvar := range s.Solution.Vars {
-		solutionCopy.Vars = append(solutionCopy.Vars, cvar)
-	}
+// AddClause adds a clause to the problem between calls of Solve. The state of
+// the heuristic is kept, the clause may also use new variables.
+func (s *Solver) AddClause(lits ...Lit) {
+	s.engine.cancelUntil(0)
+	s.engine.addClause(lits)
+}
 
-	return &Checkpoint{
-		WorkCopy: wc,
-		Solution: &solutionCopy,
+// Solve searches for an assignment satisfying all clauses in which the given
+// assumptions are true. It can be called repeatedly. The result is UNKNOWN if
+// the context is done or a budget of the options is used up first.
+func (s *Solver) Solve(ctx context.Context, assumptions ...Lit) {
+	logger.Info("Starting to solve %d clauses.\n", s.engine.db.Len())
+	s.logOpenClauses()
+
+	s.Result = UNKNOWN
+	s.CheckpointStack = &CheckpointStack{}
+	s.model = nil
+	s.failed = nil
+	s.lemmas = nil
+	s.Stats = Stats{Workers: 1}
+	propagations := s.engine.propagations
+
+	// Only assignments implied by the clauses alone stay on level 0 between
+	// calls. The assumptions are assigned on level 1, which is never
+	// backtracked, pure literals and splits follow above it.
+	s.engine.cancelUntil(0)
+	for _, lit := range assumptions {
+		s.engine.reserveVars(lit.Var())
+	}
+	s.engine.propagate()
+	s.engine.newDecisionLevel()
+	for _, lit := range assumptions {
+		switch s.engine.Value(lit) {
+		case 0:
+			s.engine.assign(lit, -1)
+		case -1:
+			logger.Info("Assumption %s is falsified.\n", lit)
+			s.failed = append(s.engine.failedAssumptions([]Lit{lit}, assumptions), lit)
+			s.Result = UNSATISFIABLE
+		}
+		if s.Result == UNSATISFIABLE {
+			break
+		}
+	}
+	if s.Result != UNSATISFIABLE {
+		s.search(ctx, assumptions)
 	}
+
+	s.Solution = s.currentSolution()
+	s.WorkCopy = s.engine.openClauses()
+	s.Stats.Propagations = s.engine.propagations - propagations
+	s.Stats.Depth = s.CheckpointStack.count
 }
 
-func (s *Solver) Solve() {
-	logger.Info("Starting to solve %d clauses.\n", len(s.WorkCopy))
-	logger.Detail("%s\n", s.WorkCopy)
+// search runs the DPLL loop from the current assignment
+func (s *Solver) search(ctx context.Context, assumptions []Lit) {
 	// while true
 	for {
-		if s.isSolved() {
-			//logger.Info("Found solution: %s\n", s.Solution.String())
-			s.Result = SATISFIABLE
-			break
-		}
-
-		if s.isUnsolvable() {
-			//logger.Info("Problem is unsolvable.\n")
-			//logger.Detail("Solution: %s\n Remaining clauses:%s\n", utils.JSONString(s.Solution), utils.JSONString(s.WorkCopy))
-			s.Result = UNSATISFIABLE
+		if cancelled(ctx) || s.options.exhausted(s.Stats.Decisions, s.Stats.Conflicts) {
+			logger.Info("Search stopped after %d decisions and %d conflicts.\n", s.Stats.Decisions, s.Stats.Conflicts)
 			break
 		}
 
-		// Check for contradictions: if any clause has all variables marked as impossible, we need to backtrack
-		if s.hasContradiction() {
+		// Propagate all unit clauses, a falsified clause means we need to backtrack
+		assigned := len(s.engine.trail)
+		start := time.Now()
+		conflict := s.engine.propagate()
+		addTime(&s.Stats.Time.Propagation, start)
+		if conflict >= 0 {
 			logger.Step("Found contradiction, backtracking...\n")
+			s.Stats.Conflicts++
+			s.heuristic.Bump(s.engine.db.Lits(conflict))
 			if s.backtrack() {
-				logger.Step("Backtracking to previous checkpoint, remaining clauses: %d\n", len(s.WorkCopy))
-				logger.Detail("%s\n", s.WorkCopy)
+				logger.Step("Backtracking to previous checkpoint, decision level: %d\n", s.engine.decisionLevel())
+				s.logOpenClauses()
 				continue
 			}
 			// No checkpoints left, problem is unsolvable
 			logger.Info("Problem is unsolvable.\n")
-			logger.Detail("Solution: %s\n Remaining clauses:%s\n", utils.JSONString(s.Solution), utils.JSONString(s.WorkCopy))
+			logger.Detail("Solution: %s\n", utils.JSONString(s.currentSolution()))
 			s.Result = UNSATISFIABLE
+			s.failed = s.engine.failedAssumptions(s.engine.db.Lits(conflict), assumptions)
+			if s.Proof != nil && len(assumptions) == 0 {
+				// The lemmas of the refuted top level splits propagate to the conflict
+				s.Proof.Add(nil)
+			}
 			break
 		}
+		if len(s.engine.trail) > assigned {
+			logger.Step("Found %d unit propagations\n", len(s.engine.trail)-assigned)
+			s.logOpenClauses()
+		}
 
-		if s.unitPropagation() {
-			logger.Step("Found a unit propagation, remaining clauses to solve: %d\n", len(s.WorkCopy))
-			logger.Detail("%s\n", s.WorkCopy)
-			continue
+		if s.isSolved() {
+			s.Result = SATISFIABLE
+			s.model = modelFromTrail(s.engine.trail)
+			break
 		}
 
 		if s.pureLiteral() {
-			logger.Step("Found a pure literal, remaining clauses to solve: %d\n", len(s.WorkCopy))
-			logger.Detail("%s\n", s.WorkCopy)
+			logger.Step("Found a pure literal\n")
+			s.logOpenClauses()
 			continue
 		}
 
 		if s.split() {
-			logger.Step("Found a split, remembering checkpoint, remaining clauses to solve: %d\n", len(s.WorkCopy))
-			logger.Detail("%s\n", s.WorkCopy)
-			continue
-		}
-
-		if s.backtrack() {
-			logger.Step("Backtracking to previous checkpoint, remaining clauses: %d\n", len(s.WorkCopy))
-			logger.Detail("%s\n", s.WorkCopy)
+			logger.Step("Found a split, remembering checkpoint, decision level: %d\n", s.engine.decisionLevel())
+			s.logOpenClauses()
 			continue
 		}
 
 		logger.Step("No resolution step found\n")
-		// TODO backtrack here i guess?
-		// otherwise UNSATISFIABLE
-		logger.Detail("%s\n", s.WorkCopy)
+		s.logOpenClauses()
 
 		break
 	}
 }
 
-func (s *Solver) isSolved() bool {
-	return len(s.WorkCopy) == 0
+// Model returns the assignment found by the last call of Solve, ordered by
+// variable, or nil if it was not satisfiable
+func (s *Solver) Model() []Lit {
+	return s.model
 }
 
-// hasContradiction checks if any clause has all its variables marked as impossible
-// This indicates we've reached a dead end and need to backtrack
-func (s *Solver) hasContradiction() bool {
-	for _, clause := range s.WorkCopy {
-		allImpossible := true
-		for _, cVar := range clause.Vars {
-			if !cVar.Impossible {
-				allImpossible = false
-				break
-			}
-		}
-		if allImpossible && len(clause.Vars) > 0 {
-			return true
-		}
-	}
-	return false
+// FailedAssumptions returns the assumptions which made the last call of Solve
+// unsatisfiable. It is empty if the clauses are unsatisfiable on their own. If
+// the search had to exhaust splits to refute the assumptions, all of them are
+// returned.
+func (s *Solver) FailedAssumptions() []Lit {
+	return s.failed
 }
 
-func (s *Solver) isUnsolvable() bool {
-	if len(s.WorkCopy) == 0 {
-		return false
+// OpenCubes returns the parts of the search space a call of Solve stopped
+// with UNKNOWN did not rule out: the current assignment and the opposite
+// choice of every open checkpoint. Each cube holds the assignments without a
+// reason leading there, solving with a cube as assumptions continues the
+// search of that part. The cubes are in the order the search would have
+// taken them, the deepest checkpoint first. It is empty after SAT or UNSAT.
+func (s *Solver) OpenCubes() [][]Lit {
+	if s.Result != UNKNOWN {
+		return [][]Lit{}
 	}
-
-	if s.CheckpointStack.count != 0 {
-		return false
+	cubes := [][]Lit{s.decisions(s.engine.trail)}
+	for i := s.CheckpointStack.count - 1; i >= 0; i-- {
+		checkpoint := s.CheckpointStack.checkpoints[i]
+		split := s.engine.trailLim[checkpoint.Level]
+		cubes = append(cubes, append(s.decisions(s.engine.trail[:split]), s.engine.trail[split].Not()))
 	}
+	return cubes
+}
 
-	for _, clause := range s.WorkCopy {
-		for _, cVar := range clause.Vars {
-			if !cVar.Impossible {
-				return false
-			}
+// decisions returns the assignments of the given trail that are neither
+// implied by a clause nor by the clauses alone
+func (s *Solver) decisions(trail []Lit) []Lit {
+	lits := make([]Lit, 0)
+	for _, lit := range trail {
+		if s.engine.reasons[lit.Var()] < 0 && s.engine.levels[lit.Var()] > 0 {
+			lits = append(lits, lit)
 		}
 	}
-	return true
+	return lits
 }
 
-// This function implements unitPropagation, returns a boolean value representing work being done (an successful reduction)
-func (s *Solver) unitPropagation() bool {
-	clauses := s.WorkCopy
+// currentSolution returns the assignments made so far as a new clause
+func (s *Solver) currentSolution() *parser.Clause {
+	return ClauseFromLits(s.engine.trail)
+}
 
-	didWork := false
+func (s *Solver) logOpenClauses() {
+	if logger.GetLevel() >= logger.FULL {
+		logger.Detail("%s\n", s.engine.openClauses())
+	}
+}
 
-	for clauseID, clause := range clauses {
-		if len(clause.Vars) == 1 {
-			unit := clause.Vars[0]
-			// We found a single variable clause -> Add it to the solution.
-			s.Solution.Vars = append(s.Solution.Vars, unit)
-
-			// Remove it from the working set
-			clauses = append(clauses[:clauseID], clauses[clauseID+1:]...)
-
-			didWork = true
-
-		preLoop:
-			// Now we need to find other clauses, containing this variable in this state and remove them from the working set
-			for otherClauseID, otherClause := range clauses {
-				for otherClauseVarID, otherClauseVar := range otherClause.Vars {
-					if otherClauseVar.ID == unit.ID {
-						if otherClauseVar.Negated == unit.Negated {
-							// this is the same Variable we just found through unit propagation with the same state. Remove the clause from the set.
-							clauses = append(clauses[:otherClauseID], clauses[otherClauseID+1:]...)
-							// we updated clauses mid loop, restart the iteration
-							goto preLoop
-						} else {
-							// this is the same Variable, but the opposite state. Mark it as impossible and update the clause in the working set.
-							otherClauseVar.Impossible = true
-							otherClause.Vars[otherClauseVarID] = otherClauseVar
-							clauses[otherClauseID] = otherClause
-						}
-					}
-				}
-			}
+// isSolved checks if every clause of the problem is satisfied by the current
+// assignment, learned clauses follow from them
+func (s *Solver) isSolved() bool {
+	defer addTime(&s.Stats.Time.SolvedChecks, time.Now())
+	for clauseID := 0; clauseID < s.engine.db.Len(); clauseID++ {
+		if !s.engine.db.IsLearned(clauseID) && !s.engine.isSatisfied(clauseID) {
+			return false
 		}
 	}
-	s.WorkCopy = clauses
-	return didWork
+	return true
 }
 
 func (s *Solver) pureLiteral() bool {
+	defer addTime(&s.Stats.Time.PureLiterals, time.Now())
 	didWork := false
 
-	clauses := s.WorkCopy
-
-	// Track which variables appear in which polarities
-	// Key: variable ID, Value: map[negated]bool (true if that polarity has been seen)
-	variablePolarity := make(map[int]map[bool]bool)
+	// Track which literals (variable and polarity) appear in open clauses
+	seen := make([]bool, 2*s.engine.db.NumVars+2)
 
-	// Scan all clauses to find polarities
-	for _, clause := range clauses {
-		for _, cVar := range clause.Vars {
-			if cVar.Impossible {
-				continue // Skip impossible variables
-			}
-			if _, ok := variablePolarity[cVar.ID]; !ok {
-				variablePolarity[cVar.ID] = make(map[bool]bool)
-			}
-			variablePolarity[cVar.ID][cVar.Negated] = true
+	// Scan all open problem clauses to find polarities
+	for clauseID := 0; clauseID < s.engine.db.Len(); clauseID++ {
+		if s.engine.db.IsLearned(clauseID) || s.engine.isSatisfied(clauseID) {
+			continue
 		}
-	}
-
-	// Find pure literals (variables that appear in only one polarity)
-	pureLiterals := make(map[int]parser.Variable)
-	for varID, polarities := range variablePolarity {
-		if len(polarities) == 1 {
-			// This variable appears in only one polarity, it's a pure literal
-			// Find the actual variable to add to solution
-			for _, clause := range clauses {
-				for _, cVar := range clause.Vars {
-					if cVar.ID == varID && !cVar.Impossible {
-						pureLiterals[varID] = cVar
-						break
-					}
-				}
-				if _, found := pureLiterals[varID]; found {
-					break
-				}
+		for _, lit := range s.engine.db.Lits(clauseID) {
+			if s.engine.Value(lit) != 0 {
+				continue // Skip assigned variables
 			}
+			seen[lit] = true
 		}
 	}
 
-	// Remove clauses containing pure literals and add them to solution
-	if len(pureLiterals) > 0 {
-		for _, pureLit := range pureLiterals {
-			s.Solution.Vars = append(s.Solution.Vars, pureLit)
-
-			// Remove all clauses containing this pure literal
-			newClauses := make([]*parser.Clause, 0)
-			for _, clause := range clauses {
-				containsPureLit := false
-				for _, cVar := range clause.Vars {
-					if cVar.ID == pureLit.ID && cVar.Negated == pureLit.Negated && !cVar.Impossible {
-						containsPureLit = true
-						break
-					}
-				}
-				if !containsPureLit {
-					newClauses = append(newClauses, clause)
-				}
-			}
-			clauses = newClauses
-			didWork = true
+	// Assign pure literals (variables that appear in only one polarity), this satisfies every clause containing them
+	for varID := 1; varID <= s.engine.db.NumVars; varID++ {
+		positive, negative := MkLit(varID, false), MkLit(varID, true)
+		if seen[positive] == seen[negative] {
+			continue
+		}
+		pureLit := positive
+		if seen[negative] {
+			pureLit = negative
+		}
+		logger.Detail("Found pure literal: %s\n", pureLit)
+		if s.Proof != nil {
+			// Every clause containing the opposite literal is satisfied, which
+			// makes the clause a resolution asymmetric tautology on the pure literal
+			s.addLemma(s.engine.decisionLevel(), pureLit, s.engine.trail)
 		}
+		s.engine.assign(pureLit, -1)
+		s.Stats.PureLiterals++
+		didWork = true
 	}
 
-	s.WorkCopy = clauses
 	return didWork
 }
 
 func (s *Solver) split() bool {
-	clauses := s.WorkCopy
-	didWork := false
+	defer addTime(&s.Stats.Time.Decisions, time.Now())
 	// At the point where split is even able to be called, there should be no "free"/"easy" variables to resolve.
-	// Count the appearances of non impossible variables and pick one with the highest count.
+	// Let the branching heuristic pick one of the variables left in the open clauses.
+	var pickedLit Lit
+	if s.look != nil {
+		lit, implied, outcome := s.look.look()
+		s.Stats.FailedLiterals += len(implied)
+		if outcome != lookBranch {
+			// Failed literals were assigned instead, propagating them solves or refutes the assignment
+			logger.Detail("Lookahead found %d failed literals\n", len(implied))
+			return true
+		}
+		pickedLit = lit
+	} else {
+		lit, ok := s.heuristic.PickBranch(s.engine)
+		if !ok {
+			// we found nothing?! wtf?!
+			return false
+		}
+		pickedLit = lit
+	}
 
-	var pickedVariable *parser.Variable
+	logger.Detail("Found a split candidate: %s\n", pickedLit)
 
-	// prepare a map to count each variable label (IDs) appearances
-	variableUsageMap := make(map[int]int)
+	// remember the current level, the opposite state is tried when backtracking to it
+	s.CheckpointStack.Push(&Checkpoint{Level: s.engine.decisionLevel()})
+	s.Stats.Decisions++
+	s.Stats.MaxDepth = max(s.Stats.MaxDepth, s.CheckpointStack.count)
 
-	// count appearances
-	for _, clause := range clauses {
-		for _, cVar := range clause.Vars {
-			// ignore impossible Vars
-			if cVar.Impossible {
-				continue
-			}
-			// check if it exists in the usage map
-			_, ok := variableUsageMap[cVar.ID]
-			if ok {
-				// increment usage
-				variableUsageMap[cVar.ID] = variableUsageMap[cVar.ID] + 1
-			} else {
-				// add it to the map
-				variableUsageMap[cVar.ID] = 1
-			}
-		}
-	}
+	// open a new decision level and assign the picked variable on it
+	s.engine.newDecisionLevel()
+	s.engine.assign(pickedLit, -1)
 
-	// find the variable with largest count (by ID)
-	maxVarID := 0
-	maxCount := 0
+	return true
+}
 
-	for variableID, count := range variableUsageMap {
-		if count > maxCount {
-			maxCount = count
-			maxVarID = variableID
-		}
+// lemma returns the clause lits OR NOT(assignments without reason among the
+// given trail), i.e. one of lits is implied by the decisions taken so far
+func (s *Solver) lemma(trail []Lit, lits ...Lit) []Lit {
+	lits = append([]Lit{}, lits...)
+	for _, assigned := range s.decisions(trail) {
+		lits = append(lits, assigned.Not())
 	}
+	return lits
+}
 
-	if maxVarID == 0 {
-		// we found nothing?! wtf?!
-		return didWork
-	}
+// addLemma writes the lemma of lit under the given trail to the proof
+func (s *Solver) addLemma(level int, lit Lit, trail []Lit) {
+	lits := s.lemma(trail, lit)
+	s.Proof.Add(lits)
+	s.lemmas = append(s.lemmas, proofLemma{level: level, lits: lits})
+}
 
-	// find the first variable occurance matching the ID we just detected
-	for _, clause := range clauses {
-		if pickedVariable != nil {
-			break
-		}
-		for _, cVar := range clause.Vars {
-			if cVar.ID == maxVarID {
-				// found it, pick it
-				pickedVariable = &cVar
-
-				logger.Detail("Found a split candidate: %s\n", cVar.String())
-
-				// remember this for the checkpoint, pick the opposite state in the checkpoint
-				checkpoint := s.markCheckpoint()
-
-				checkpointVar := &parser.Variable{
-					ID:         cVar.ID,
-					Impossible: cVar.Impossible,
-				}
-				if cVar.Negated {
-					checkpointVar.Negated = false
-				} else {
-					checkpointVar.Negated = true
-				}
-
-				logger.Detail("CheckpointVar: %s\n", checkpointVar.String())
-
-				checkpoint.Solution.Vars = append(checkpoint.Solution.Vars, *checkpointVar)
-				s.CheckpointStack.Push(checkpoint)
-
-				// add it to the current solution
-				s.Solution.Vars = append(s.Solution.Vars, cVar)
-				logger.Detail("checkpoint Solution %s\n", checkpoint.Solution)
-				logger.Detail("solver solution: %s\n", s.Solution)
-				break
-			}
+// deleteLemmas removes the lemmas of assignments above the given level from
+// the proof, except the most recent one
+func (s *Solver) deleteLemmas(level int) {
+	last := s.lemmas[len(s.lemmas)-1]
+	kept := s.lemmas[:0]
+	for _, lemma := range s.lemmas[:len(s.lemmas)-1] {
+		if lemma.level > level {
+			s.Proof.Delete(lemma.lits)
+		} else {
+			kept = append(kept, lemma)
 		}
 	}
+	s.lemmas = append(kept, last)
+}
 
-	didWork = s.reduceWorkingSet(pickedVariable)
-
-	return didWork
+// leaveOpen marks the branches of the open checkpoints as not refuted, e.g.
+// when a part of them is handed to other workers or holds a solution
+func (s *Solver) leaveOpen() {
+	for _, checkpoint := range s.CheckpointStack.checkpoints[:s.CheckpointStack.count] {
+		checkpoint.Partial = true
+	}
+	s.partial = true
 }
 
 func (s *Solver) backtrack() bool {
-	if s.CheckpointStack.count == 0 {
+	defer addTime(&s.Stats.Time.Backtracking, time.Now())
+	backtrackPoint := s.CheckpointStack.Pop()
+	if backtrackPoint == nil {
 		logger.Detail("No more checkpoints to backtrack to\n")
 		return false
 	}
 
-	logger.Detail("CPS Pre backtrack: %v\n", s.CheckpointStack)
-	logger.Detail("WorkCopy: %s\n", s.WorkCopy)
-	logger.Detail("Solution: %s\n", s.Solution)
-
-	stack := *s.CheckpointStack
-	backtrackPoint := *stack.Pop()
+	logger.Detail("Backtracking from level %d to level %d\n", s.engine.decisionLevel(), backtrackPoint.Level)
+	s.Stats.Backtracks++
 
-	logger.Detail("%v\n", backtrackPoint)
-
-	s.CheckpointStack = &stack
-
-	sol := *backtrackPoint.Solution
-
-	// Create a new slice to avoid aliasing with the checkpoint's WorkCopy
-	restoredWorkCopy := make([]*parser.Clause, len(backtrackPoint.WorkCopy))
-	copy(restoredWorkCopy, backtrackPoint.WorkCopy)
-	s.WorkCopy = restoredWorkCopy
-	s.Solution = &sol
+	// undo every assignment made since the split and take the opposite choice,
+	// if the first choice failed the alternative is forced at the split's level
+	alternative := s.engine.trail[s.engine.trailLim[backtrackPoint.Level]].Not()
+	if s.learn != nil && !backtrackPoint.Partial {
+		s.learn(s.lemma(s.engine.trail[:s.engine.trailLim[backtrackPoint.Level]], alternative))
+	}
+	if s.Proof != nil && !backtrackPoint.Partial {
+		// The split failed under the assignments before it. The lemmas of its
+		// subtree derive that by unit propagation and are not needed anymore.
+		s.addLemma(backtrackPoint.Level, alternative, s.engine.trail[:s.engine.trailLim[backtrackPoint.Level]])
+		s.deleteLemmas(backtrackPoint.Level)
+	}
+	s.engine.cancelUntil(backtrackPoint.Level)
+	s.engine.assign(alternative, -1)
 
-	logger.Detail("CPS Post backtrack: %v\n", s.CheckpointStack)
-	logger.Detail("WorkCopy: %s\n", s.WorkCopy)
-	logger.Detail("Solution: %s\n", s.Solution)
+	logger.Detail("CheckpointVar: %s\n", alternative)
 
-	// reduce with the last variabele (the variable that caused the split in the first place)
-	s.reduceWorkingSet(&sol.Vars[len(sol.Vars)-1])
+	logger.Detail("Solution: %s\n", s.currentSolution())
 
 	return true
 }
-
-func (s *Solver) reduceWorkingSet(rVar *parser.Variable) bool {
-	clauses := s.WorkCopy
-	didWork := false
-preLoop:
-	// Remove clauses with this variable state (they are solved), mark opposite state as impossible
-	for clauseID, clause := range clauses {
-		for cVarID, cVar := range clause.Vars {
-			if cVar.Impossible {
-				continue
-			}
-			if cVar.ID == rVar.ID {
-				if cVar.Negated == rVar.Negated {
-					// clause contains variable with the same negation state, remove the entire clause as it is solved
-					logger.Detail("Clause %s (ID: %d) contains variable %s, removing...\n", clause, clauseID, rVar)
-					clauses = append(clauses[:clauseID], clauses[clauseID+1:]...)
-					goto preLoop
-				} else {
-					cVar.Impossible = true
-					clause.Vars[cVarID] = cVar
-					clauses[clauseID] = clause
-				}
-				didWork = true
-			}
-		}
-	}
-	s.WorkCopy = clauses
-	return didWork
-}
@@ -0,0 +1,48 @@
+package solver
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSolverCheckpoints(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uf50-218")[0])
+	s := NewSolver(task, DefaultOptions())
+
+	// Every split remembers the level it was made at, its variable is the
+	// first assignment of the next level
+	trails := make([][]Lit, 0)
+	splits := make([]Lit, 0)
+	for level := range 3 {
+		if conflict := s.engine.propagate(); conflict >= 0 {
+			t.Fatalf("conflict in clause %d after %d splits", conflict, level)
+		}
+		trails = append(trails, slices.Clone(s.engine.trail))
+		if !s.split() {
+			t.Fatalf("no split after %d splits", level)
+		}
+		checkpoint := s.CheckpointStack.checkpoints[s.CheckpointStack.count-1]
+		if checkpoint.Level != level {
+			t.Errorf("checkpoint of split %d at level %d", level+1, checkpoint.Level)
+		}
+		splits = append(splits, s.engine.trail[s.engine.trailLim[level]])
+	}
+
+	// Backtracking undoes the trail down to the checkpoint's level and takes
+	// the opposite choice there
+	for level := 2; level >= 0; level-- {
+		if !s.backtrack() {
+			t.Fatalf("no checkpoint at level %d", level)
+		}
+		want := append(slices.Clone(trails[level]), splits[level].Not())
+		if !slices.Equal(s.engine.trail, want) {
+			t.Errorf("trail %v after backtracking to level %d, want %v", s.engine.trail, level, want)
+		}
+		if s.engine.decisionLevel() != level {
+			t.Errorf("decision level %d after backtracking to level %d", s.engine.decisionLevel(), level)
+		}
+	}
+	if s.backtrack() {
+		t.Error("backtracked without a checkpoint")
+	}
+}
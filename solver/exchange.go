@@ -0,0 +1,81 @@
+package solver
+
+import "sync"
+
+// clauseExchange passes learned clauses between the workers of the parallel
+// solver. A worker keeps the clauses it offers in an outbox of its own and
+// publishes them in a batch when it syncs, so the lock is only taken once in a
+// while. Every worker then imports the clauses the others published since its
+// last sync.
+type clauseExchange struct {
+	maxSize int // Longest clause that is shared
+	maxLBD  int // Highest number of distinct decision levels of a shared clause
+
+	mu        sync.Mutex
+	published []sharedClause   // Clauses of all workers, only appended to during a call of Solve
+	outboxes  [][]sharedClause // Per worker: clauses not published yet
+	imported  []int            // Per worker: number of published clauses it has seen
+}
+
+// sharedClause is a learned clause together with the worker that learned it
+type sharedClause struct {
+	lits     []Lit
+	lbd      int // Decision levels its literals spanned when it was learned
+	workerID int
+}
+
+func newClauseExchange(numWorkers int, maxSize int, maxLBD int) *clauseExchange {
+	return &clauseExchange{
+		maxSize:  maxSize,
+		maxLBD:   maxLBD,
+		outboxes: make([][]sharedClause, numWorkers),
+		imported: make([]int, numWorkers),
+	}
+}
+
+// offer puts a clause learned by a worker into its outbox if it is short
+// enough and its literals were assigned on few enough decision levels. It
+// reports whether the clause will be shared.
+func (ex *clauseExchange) offer(workerID int, lits []Lit, engine *propagator) bool {
+	lbd := engine.levelCount(lits)
+	if len(lits) > ex.maxSize || lbd > ex.maxLBD {
+		return false
+	}
+	ex.outboxes[workerID] = append(ex.outboxes[workerID], sharedClause{lits: append([]Lit{}, lits...), lbd: lbd, workerID: workerID})
+	return true
+}
+
+// sync publishes the outbox of a worker and returns the clauses the other
+// workers published since its last sync
+func (ex *clauseExchange) sync(workerID int) []sharedClause {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	ex.published = append(ex.published, ex.outboxes[workerID]...)
+	ex.outboxes[workerID] = ex.outboxes[workerID][:0]
+
+	clauses := make([]sharedClause, 0)
+	for _, clause := range ex.published[ex.imported[workerID]:] {
+		if clause.workerID != workerID {
+			clauses = append(clauses, clause)
+		}
+	}
+	ex.imported[workerID] = len(ex.published)
+	return clauses
+}
+
+// importedClauses are the clauses a worker took from the exchange. They are
+// stored as learned clauses, so they are reduced like the ones of the CDCL
+// solver instead of piling up, and do not count as part of the problem.
+type importedClauses struct {
+	learned    *learnedClauses
+	nextReduce int            // Imported clauses at which they are reduced next, 0 to never reduce
+	deferred   []sharedClause // Unit clauses imported above level 0, added at the start of the next work item
+}
+
+func newImportedClauses(reduceInterval int) *importedClauses {
+	return &importedClauses{
+		learned:    newLearnedClauses(),
+		nextReduce: reduceInterval,
+	}
+}
@@ -0,0 +1,193 @@
+package solver
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// decide opens a decision level for each literal, so the i-th literal is
+// assigned on level i+1
+func decide(p *propagator, lits ...Lit) {
+	for _, lit := range lits {
+		p.newDecisionLevel()
+		p.assign(lit, -1)
+	}
+}
+
+func TestClauseExchangeOffer(t *testing.T) {
+	engine := newPropagator(NewClauseDB(taskFromLits(6, nil)))
+	decide(engine, pos(1), pos(2), pos(3))
+	ex := newClauseExchange(2, 3, 2)
+
+	tests := []struct {
+		lits   []Lit
+		shared bool
+	}{
+		{[]Lit{neg(1), neg(2)}, true},
+		{[]Lit{neg(1), neg(2), neg(3)}, false},         // Spans 3 levels
+		{[]Lit{neg(1), neg(2), pos(4), pos(5)}, false}, // Too long
+		{[]Lit{neg(3), pos(4), pos(5)}, true},          // The unassigned variables are on level 0
+	}
+	for _, tt := range tests {
+		if shared := ex.offer(0, tt.lits, engine); shared != tt.shared {
+			t.Errorf("offer(%v) = %v, want %v", tt.lits, shared, tt.shared)
+		}
+	}
+	if len(ex.outboxes[0]) != 2 || ex.outboxes[0][0].lbd != 2 {
+		t.Errorf("outbox %v, want the two shared clauses", ex.outboxes[0])
+	}
+}
+
+func TestClauseExchangeSync(t *testing.T) {
+	engine := newPropagator(NewClauseDB(taskFromLits(3, nil)))
+	ex := newClauseExchange(3, 8, 8)
+
+	// Offered clauses stay in the outbox until their worker syncs
+	lits := []Lit{pos(1), pos(2)}
+	ex.offer(0, lits, engine)
+	lits[0] = neg(3)
+	if clauses := ex.sync(1); len(clauses) != 0 {
+		t.Fatalf("worker 1 imported %d clauses before worker 0 synced", len(clauses))
+	}
+	if clauses := ex.sync(0); len(clauses) != 0 {
+		t.Errorf("worker 0 imported %d of its own clauses", len(clauses))
+	}
+
+	for _, workerID := range []int{1, 2} {
+		clauses := ex.sync(workerID)
+		if len(clauses) != 1 || clauses[0].workerID != 0 {
+			t.Fatalf("worker %d imported %v, want the clause of worker 0", workerID, clauses)
+		}
+		if got := clauses[0].lits; len(got) != 2 || got[0] != pos(1) || got[1] != pos(2) {
+			t.Errorf("worker %d imported %v, want a copy of the offered clause", workerID, got)
+		}
+		if clauses := ex.sync(workerID); len(clauses) != 0 {
+			t.Errorf("worker %d imported %d clauses twice", workerID, len(clauses))
+		}
+	}
+}
+
+func TestAddImported(t *testing.T) {
+	engine := newPropagator(NewClauseDB(taskFromLits(5, nil)))
+	decide(engine, pos(1), pos(2), pos(3))
+
+	// Unit under the assignment: the open literal is assigned right away
+	clauseID, falsified := engine.addImported([]Lit{neg(1), pos(4), neg(2)})
+	if falsified {
+		t.Error("unit clause reported as falsified")
+	}
+	if engine.Value(pos(4)) != 1 || engine.reasons[4] != clauseID {
+		t.Error("unit clause not propagated")
+	}
+	if !engine.IsLearned(clauseID) {
+		t.Error("imported clause not stored as learned")
+	}
+
+	// Falsified: the literals assigned last are watched
+	clauseID, falsified = engine.addImported([]Lit{neg(1), neg(3), neg(2)})
+	if !falsified {
+		t.Error("falsified clause not reported")
+	}
+	if lits := engine.db.Lits(clauseID); lits[0] != neg(3) || lits[1] != neg(2) {
+		t.Errorf("falsified clause stored as %v, want the last levels first", lits)
+	}
+	if engine.conflict >= 0 {
+		t.Error("conflict above level 0 stored as permanent")
+	}
+
+	// Backtracking keeps the watches valid
+	engine.cancelUntil(0)
+	decide(engine, pos(1), pos(2))
+	if conflict := engine.propagate(); conflict >= 0 || engine.Value(pos(4)) != 1 || engine.Value(pos(3)) != -1 {
+		t.Errorf("imported clauses not propagated after backtracking: conflict %d, trail %v", conflict, engine.trail)
+	}
+}
+
+// checkImplied makes sure every shared clause follows from the clauses of
+// the task, i.e. they are unsatisfiable together with its negation
+func checkImplied(t *testing.T, task *parser.Task, clauses []sharedClause) {
+	t.Helper()
+	check := NewCDCLSolver(task, DefaultOptions())
+	for _, clause := range clauses {
+		negated := make([]Lit, len(clause.lits))
+		for i, lit := range clause.lits {
+			negated[i] = lit.Not()
+		}
+		if check.Solve(context.Background(), negated...); check.Result != UNSATISFIABLE {
+			t.Errorf("%s: worker %d shared %v, which does not follow from the clauses", task.Name, clause.workerID, clause.lits)
+		}
+	}
+}
+
+func TestParallelClauseSharing(t *testing.T) {
+	tests := []struct {
+		folder string
+		want   Result
+	}{
+		{"uf50-218", SATISFIABLE},
+		{"uuf50-218", UNSATISFIABLE},
+	}
+	shared := 0
+	for _, tt := range tests {
+		for _, file := range exampleFiles(t, tt.folder) {
+			task := loadTask(t, file)
+			options := DefaultOptions()
+			options.ShareSize = 50
+			options.ShareLBD = 50
+			options.ReduceInterval = 10
+			ps := NewParallelSolver(task, 4, 0, false, options)
+
+			result, solution := ps.Solve(context.Background())
+			if result != tt.want {
+				t.Fatalf("%s: result %s, want %s", filepath.Base(file), result, tt.want)
+			}
+			if result == SATISFIABLE {
+				if err := Verify(task, solution); err != nil {
+					t.Errorf("%s: solution rejected: %v", filepath.Base(file), err)
+				}
+			}
+			for _, worker := range ps.Stats.WorkItems {
+				shared += worker.Shared
+			}
+			checkImplied(t, task, ps.exchange.published)
+		}
+	}
+	if shared == 0 {
+		t.Error("no clauses were shared")
+	}
+}
+
+// fillingQueue lets the deques fill up again as soon as it picks a branch, so
+// a worker splits sequentially first and in parallel below the checkpoint
+type fillingQueue struct {
+	BranchingHeuristic
+	ps *ParallelSolver
+}
+
+func (h fillingQueue) PickBranch(state SearchState) (Lit, bool) {
+	h.ps.queued.Store(0)
+	return h.BranchingHeuristic.PickBranch(state)
+}
+
+func TestParallelSplitBelowCheckpointIsNotShared(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uf50-218")[0])
+	options := DefaultOptions()
+	options.ShareSize = 50
+	options.ShareLBD = 50
+	ps := NewParallelSolver(task, 2, 0, false, options)
+	ps.reset()
+
+	// The deques are full, so the first split gets a checkpoint. The splits
+	// after it are handed to the deques, the branch of the checkpoint is not
+	// refuted by backtracking over them.
+	ps.queued.Store(int64(ps.maxQueueSize))
+	ps.processWorkItem(&WorkItem{}, ps.engines[0], fillingQueue{ps.heuristics[0], ps}, 0)
+	if ps.workItems[0].Created == 0 {
+		t.Fatal("no parallel split below the checkpoint")
+	}
+
+	checkImplied(t, task, ps.exchange.outboxes[0])
+}
@@ -0,0 +1,306 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// SearchState is the view of the current search state available to branching heuristics
+type SearchState interface {
+	NumVars() int
+	NumClauses() int
+	ClauseLits(clauseID int) []Lit
+	IsLearned(clauseID int) bool // Learned clauses follow from the others and are not counted
+	Value(lit Lit) int8          // 1 if the literal is true, -1 if it is false and 0 if it is unassigned
+}
+
+// BranchingHeuristic picks the literal to split on once propagation is done
+type BranchingHeuristic interface {
+	// PickBranch returns the literal to assign next. It returns false if every
+	// clause is satisfied by the current assignment.
+	PickBranch(state SearchState) (Lit, bool)
+
+	// Bump is called with the literals of every conflict, i.e. the falsified
+	// clause in DPLL or the learned clause in CDCL
+	Bump(lits []Lit)
+}
+
+// BacktrackListener is implemented by heuristics that need to know when an
+// assignment is undone, e.g. to make the variable available again
+type BacktrackListener interface {
+	Unassigned(lit Lit)
+}
+
+type HeuristicKind int
+
+const (
+	DLCS   HeuristicKind = iota // Dynamic largest combined sum of occurrences (default)
+	DLIS                        // Dynamic largest individual sum of occurrences
+	MOMS                        // Maximum occurrences in clauses of minimum size
+	JW                          // One-sided Jeroslow-Wang
+	JW2                         // Two-sided Jeroslow-Wang
+	RANDOM                      // Random open variable and polarity
+	VSIDS                       // Variable state independent decaying sum with phase saving
+)
+
+func (h HeuristicKind) String() string {
+	return [...]string{"dlcs", "dlis", "moms", "jw", "jw2", "random", "vsids"}[h]
+}
+
+// ParseHeuristic converts a string to a HeuristicKind
+func ParseHeuristic(heuristicStr string) (HeuristicKind, error) {
+	for kind := DLCS; kind <= VSIDS; kind++ {
+		if kind.String() == heuristicStr {
+			return kind, nil
+		}
+	}
+	return DLCS, fmt.Errorf("unknown heuristic '%s', expected one of 'dlcs', 'dlis', 'moms', 'jw', 'jw2', 'random' or 'vsids'", heuristicStr)
+}
+
+// NewHeuristic creates a heuristic of the given kind. Heuristics keep state
+// between calls, so every solver (and every parallel worker) needs its own.
+func NewHeuristic(kind HeuristicKind, numVars int, seed int64) BranchingHeuristic {
+	switch kind {
+	case DLIS:
+		return &dlis{}
+	case MOMS:
+		return &moms{}
+	case JW:
+		return &jeroslowWang{}
+	case JW2:
+		return &jeroslowWang{twoSided: true}
+	case RANDOM:
+		return &randomHeuristic{rng: rand.New(rand.NewSource(seed))}
+	case VSIDS:
+		return newVSIDS(numVars)
+	default:
+		return &dlcs{}
+	}
+}
+
+type Polarity int
+
+const (
+	HeuristicPolarity Polarity = iota // Decide in the polarity the heuristic picks (default)
+	PositivePolarity                  // Always decide the variable true
+	NegativePolarity                  // Always decide the variable false
+)
+
+func (p Polarity) String() string {
+	return [...]string{"heuristic", "positive", "negative"}[p]
+}
+
+// ParsePolarity converts a string to a Polarity
+func ParsePolarity(polarityStr string) (Polarity, error) {
+	for polarity := HeuristicPolarity; polarity <= NegativePolarity; polarity++ {
+		if polarity.String() == polarityStr {
+			return polarity, nil
+		}
+	}
+	return HeuristicPolarity, fmt.Errorf("unknown polarity '%s', expected 'heuristic', 'positive' or 'negative'", polarityStr)
+}
+
+// withPolarity makes a heuristic decide its variables in a fixed polarity.
+// The heuristic is returned unchanged for HeuristicPolarity.
+func withPolarity(heuristic BranchingHeuristic, polarity Polarity) BranchingHeuristic {
+	if polarity == HeuristicPolarity {
+		return heuristic
+	}
+	return &fixedPolarity{BranchingHeuristic: heuristic, negated: polarity == NegativePolarity}
+}
+
+// fixedPolarity picks the variables of another heuristic but decides all of
+// them in the same polarity
+type fixedPolarity struct {
+	BranchingHeuristic
+	negated bool
+}
+
+func (h *fixedPolarity) PickBranch(state SearchState) (Lit, bool) {
+	lit, ok := h.BranchingHeuristic.PickBranch(state)
+	return MkLit(lit.Var(), h.negated), ok
+}
+
+// Unassigned passes backtracking notifications on to heuristics that need them
+func (h *fixedPolarity) Unassigned(lit Lit) {
+	if listener, ok := h.BranchingHeuristic.(BacktrackListener); ok {
+		listener.Unassigned(lit)
+	}
+}
+
+// forEachOpenClause calls fn with the unassigned literals of every problem
+// clause not satisfied by the current assignment and reports whether there was
+// any. The slice passed to fn is reused between calls.
+func forEachOpenClause(state SearchState, fn func(unassigned []Lit)) bool {
+	open := false
+	unassigned := make([]Lit, 0)
+
+	for clauseID := 0; clauseID < state.NumClauses(); clauseID++ {
+		if state.IsLearned(clauseID) {
+			continue
+		}
+		unassigned = unassigned[:0]
+		satisfied := false
+		for _, lit := range state.ClauseLits(clauseID) {
+			switch state.Value(lit) {
+			case 1:
+				satisfied = true
+			case 0:
+				unassigned = append(unassigned, lit)
+			}
+			if satisfied {
+				break
+			}
+		}
+		if satisfied || len(unassigned) == 0 {
+			continue
+		}
+		open = true
+		fn(unassigned)
+	}
+
+	return open
+}
+
+// bestVariable returns the literal of the variable with the highest combined
+// score of both polarities, in the polarity with the higher individual score.
+// Ties are broken by the lowest variable ID.
+func bestVariable(scores []float64, combine func(pos, neg float64) float64) Lit {
+	best := Lit(0)
+	bestScore := math.Inf(-1)
+	for id := 1; 2*id+1 < len(scores); id++ {
+		pos, neg := scores[MkLit(id, false)], scores[MkLit(id, true)]
+		if pos == 0 && neg == 0 {
+			continue
+		}
+		if score := combine(pos, neg); score > bestScore {
+			bestScore = score
+			best = MkLit(id, neg > pos)
+		}
+	}
+	return best
+}
+
+func sum(pos, neg float64) float64 {
+	return pos + neg
+}
+
+// dlcs picks the variable occurring most often in open clauses, counting both polarities
+type dlcs struct{}
+
+func (h *dlcs) PickBranch(state SearchState) (Lit, bool) {
+	counts := make([]float64, 2*state.NumVars()+2)
+	open := forEachOpenClause(state, func(unassigned []Lit) {
+		for _, lit := range unassigned {
+			counts[lit]++
+		}
+	})
+	if !open {
+		return 0, false
+	}
+	return bestVariable(counts, sum), true
+}
+
+func (h *dlcs) Bump(lits []Lit) {}
+
+// dlis picks the single literal occurring most often in open clauses
+type dlis struct{}
+
+func (h *dlis) PickBranch(state SearchState) (Lit, bool) {
+	counts := make([]float64, 2*state.NumVars()+2)
+	open := forEachOpenClause(state, func(unassigned []Lit) {
+		for _, lit := range unassigned {
+			counts[lit]++
+		}
+	})
+	if !open {
+		return 0, false
+	}
+	return bestVariable(counts, math.Max), true
+}
+
+func (h *dlis) Bump(lits []Lit) {}
+
+// moms picks the variable occurring most often in the shortest open clauses,
+// preferring variables that occur in both polarities
+type moms struct{}
+
+// momsWeight scales the combined count so it dominates the balance term
+const momsWeight = 1 << 10
+
+func (h *moms) PickBranch(state SearchState) (Lit, bool) {
+	minSize := math.MaxInt
+	forEachOpenClause(state, func(unassigned []Lit) {
+		minSize = min(minSize, len(unassigned))
+	})
+
+	counts := make([]float64, 2*state.NumVars()+2)
+	open := forEachOpenClause(state, func(unassigned []Lit) {
+		if len(unassigned) != minSize {
+			return
+		}
+		for _, lit := range unassigned {
+			counts[lit]++
+		}
+	})
+	if !open {
+		return 0, false
+	}
+	return bestVariable(counts, func(pos, neg float64) float64 {
+		return (pos+neg)*momsWeight + pos*neg
+	}), true
+}
+
+func (h *moms) Bump(lits []Lit) {}
+
+// jeroslowWang weighs every occurrence in an open clause with 2^-size, so
+// literals of short clauses count most. The one-sided variant picks the best
+// literal, the two-sided variant the best variable.
+type jeroslowWang struct {
+	twoSided bool
+}
+
+func (h *jeroslowWang) PickBranch(state SearchState) (Lit, bool) {
+	scores := make([]float64, 2*state.NumVars()+2)
+	open := forEachOpenClause(state, func(unassigned []Lit) {
+		weight := math.Pow(2, -float64(len(unassigned)))
+		for _, lit := range unassigned {
+			scores[lit] += weight
+		}
+	})
+	if !open {
+		return 0, false
+	}
+	if h.twoSided {
+		return bestVariable(scores, sum), true
+	}
+	return bestVariable(scores, math.Max), true
+}
+
+func (h *jeroslowWang) Bump(lits []Lit) {}
+
+// randomHeuristic picks a random variable of the open clauses with a random polarity
+type randomHeuristic struct {
+	rng *rand.Rand
+}
+
+func (h *randomHeuristic) PickBranch(state SearchState) (Lit, bool) {
+	candidates := make([]Lit, 0)
+	seen := make([]bool, state.NumVars()+1)
+	open := forEachOpenClause(state, func(unassigned []Lit) {
+		for _, lit := range unassigned {
+			if !seen[lit.Var()] {
+				seen[lit.Var()] = true
+				candidates = append(candidates, lit)
+			}
+		}
+	})
+	if !open {
+		return 0, false
+	}
+	lit := candidates[h.rng.Intn(len(candidates))]
+	return MkLit(lit.Var(), h.rng.Intn(2) == 1), true
+}
+
+func (h *randomHeuristic) Bump(lits []Lit) {}
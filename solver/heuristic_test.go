@@ -0,0 +1,109 @@
+package solver
+
+import (
+	"testing"
+)
+
+// heuristicTask has a different best literal for every counting heuristic
+func heuristicTask() *propagator {
+	return newPropagator(NewClauseDB(taskFromLits(6, [][]Lit{
+		{neg(1), pos(4)},
+		{pos(1), neg(5)},
+		{neg(2), neg(6), neg(4)},
+		{pos(3), neg(2), neg(4)},
+		{neg(5), neg(2)},
+		{neg(5), neg(2), neg(1), neg(4)},
+		{pos(4), neg(1)},
+		{pos(3), neg(2), neg(4), pos(5)},
+		{pos(4), neg(5), neg(6)},
+	})))
+}
+
+func TestHeuristicPicks(t *testing.T) {
+	tests := []struct {
+		kind HeuristicKind
+		want Lit
+	}{
+		{DLCS, neg(4)}, // 7 occurrences of variable 4, most of them negative
+		{DLIS, neg(2)}, // -2 occurs 5 times
+		{MOMS, neg(1)}, // 1 occurs in 3 binary clauses, in both polarities
+		{JW, neg(5)},   // -5 occurs in 2 binary clauses
+		{JW2, pos(4)},  // 4 occurs in 3 short clauses, -4 in longer ones
+	}
+	for _, tt := range tests {
+		engine := heuristicTask()
+		lit, ok := NewHeuristic(tt.kind, engine.NumVars(), 1).PickBranch(engine)
+		if !ok || lit != tt.want {
+			t.Errorf("%s picked %s, %v, want %s", tt.kind, lit, ok, tt.want)
+		}
+
+		// Nothing is left to pick once every clause is satisfied
+		engine.newDecisionLevel()
+		for _, lit := range []Lit{neg(1), neg(2), neg(4), neg(5)} {
+			engine.assign(lit, -1)
+		}
+		if lit, ok := NewHeuristic(tt.kind, engine.NumVars(), 1).PickBranch(engine); ok {
+			t.Errorf("%s picked %s with every clause satisfied", tt.kind, lit)
+		}
+	}
+}
+
+func TestRandomHeuristic(t *testing.T) {
+	engine := heuristicTask()
+	engine.newDecisionLevel()
+	for _, lit := range []Lit{neg(1), neg(2), neg(4)} {
+		engine.assign(lit, -1)
+	}
+
+	// Only variables 5 and 6 of the last clause are left open
+	first, second := NewHeuristic(RANDOM, engine.NumVars(), 7), NewHeuristic(RANDOM, engine.NumVars(), 7)
+	for range 20 {
+		lit, ok := first.PickBranch(engine)
+		if !ok || (lit.Var() != 5 && lit.Var() != 6) {
+			t.Fatalf("picked %s, %v, want a variable of the open clause", lit, ok)
+		}
+		if other, _ := second.PickBranch(engine); other != lit {
+			t.Fatalf("picked %s and %s with the same seed", lit, other)
+		}
+	}
+}
+
+func TestParseHeuristic(t *testing.T) {
+	for kind := DLCS; kind <= VSIDS; kind++ {
+		if parsed, err := ParseHeuristic(kind.String()); err != nil || parsed != kind {
+			t.Errorf("ParseHeuristic(%q) = %s, %v", kind.String(), parsed, err)
+		}
+	}
+	for _, name := range []string{"", "DLCS", "vsid", "jw3"} {
+		if _, err := ParseHeuristic(name); err == nil {
+			t.Errorf("ParseHeuristic(%q) accepted", name)
+		}
+	}
+}
+
+func TestFixedPolarity(t *testing.T) {
+	engine := heuristicTask()
+	tests := []struct {
+		polarity Polarity
+		want     Lit
+	}{
+		{HeuristicPolarity, neg(4)},
+		{PositivePolarity, pos(4)},
+		{NegativePolarity, neg(4)},
+	}
+	for _, tt := range tests {
+		heuristic := withPolarity(NewHeuristic(DLCS, engine.NumVars(), 1), tt.polarity)
+		if lit, ok := heuristic.PickBranch(engine); !ok || lit != tt.want {
+			t.Errorf("%s polarity picked %s, %v, want %s", tt.polarity, lit, ok, tt.want)
+		}
+	}
+
+	for polarity := HeuristicPolarity; polarity <= NegativePolarity; polarity++ {
+		if parsed, err := ParsePolarity(polarity.String()); err != nil || parsed != polarity {
+			t.Errorf("ParsePolarity(%q) = %s, %v", polarity.String(), parsed, err)
+		}
+	}
+	if _, err := ParsePolarity("true"); err == nil {
+		t.Error("ParsePolarity(\"true\") accepted")
+	}
+}
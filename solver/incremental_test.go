@@ -0,0 +1,237 @@
+package solver
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// incrementalSolver is the API shared by the sequential solvers
+type incrementalSolver interface {
+	AddClause(lits ...Lit)
+	Solve(ctx context.Context, assumptions ...Lit)
+	Model() []Lit
+	FailedAssumptions() []Lit
+}
+
+// sequentialSolvers creates one of each sequential solver together with a
+// function returning its last result
+func sequentialSolvers(numVars int, clauses [][]Lit, options Options) map[string]func() (incrementalSolver, func() Result) {
+	task := taskFromLits(numVars, clauses)
+	return map[string]func() (incrementalSolver, func() Result){
+		"dpll": func() (incrementalSolver, func() Result) {
+			s := NewSolver(task, options)
+			return s, func() Result { return s.Result }
+		},
+		"lookahead": func() (incrementalSolver, func() Result) {
+			s := NewLookaheadSolver(task, options)
+			return s, func() Result { return s.Result }
+		},
+		"cdcl": func() (incrementalSolver, func() Result) {
+			s := NewCDCLSolver(task, options)
+			return s, func() Result { return s.Result }
+		},
+	}
+}
+
+// modelSatisfies reports whether a model makes the clauses and assumptions true
+func modelSatisfies(model []Lit, clauses [][]Lit, assumptions []Lit) bool {
+	return satisfiedBy(func(lit Lit) bool { return slices.Contains(model, lit) }, clauses, assumptions)
+}
+
+// checkFailedAssumptions makes sure the failed assumptions of an
+// unsatisfiable call are some of the assumptions and enough for a refutation
+func checkFailedAssumptions(t *testing.T, numVars int, clauses [][]Lit, assumptions []Lit, failed []Lit) {
+	t.Helper()
+	for _, lit := range failed {
+		if !slices.Contains(assumptions, lit) {
+			t.Errorf("failed assumption %s is not one of %v", lit, assumptions)
+		}
+	}
+	if bruteForce(numVars, clauses, failed) {
+		t.Errorf("failed assumptions %v of %v are satisfiable", failed, assumptions)
+	}
+}
+
+// bruteForce reports whether the clauses have a model in which the
+// assumptions are true by trying every assignment
+func bruteForce(numVars int, clauses [][]Lit, assumptions []Lit) bool {
+	for bits := 0; bits < 1<<numVars; bits++ {
+		isTrue := func(lit Lit) bool {
+			return (bits>>(lit.Var()-1)&1 == 1) != lit.Negated()
+		}
+		if satisfiedBy(isTrue, clauses, assumptions) {
+			return true
+		}
+	}
+	return false
+}
+
+// satisfiedBy reports whether the clauses and assumptions are true under the
+// given assignment
+func satisfiedBy(isTrue func(Lit) bool, clauses [][]Lit, assumptions []Lit) bool {
+	for _, lit := range assumptions {
+		if !isTrue(lit) {
+			return false
+		}
+	}
+	for _, clause := range clauses {
+		if !slices.ContainsFunc(clause, isTrue) {
+			return false
+		}
+	}
+	return true
+}
+
+// randomClause returns a clause of distinct variables
+func randomClause(r *rand.Rand, numVars int, size int) []Lit {
+	vars := r.Perm(numVars)[:size]
+	lits := make([]Lit, size)
+	for i, v := range vars {
+		lits[i] = MkLit(v+1, r.Intn(2) == 0)
+	}
+	return lits
+}
+
+func TestIncrementalSolvingMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for formula := 0; formula < 150; formula++ {
+		numVars := 4 + r.Intn(5)
+		clauses := make([][]Lit, 0)
+		for i := 0; i < 2+r.Intn(4*numVars); i++ {
+			clauses = append(clauses, randomClause(r, numVars, 1+r.Intn(3)))
+		}
+		half := len(clauses) / 2
+
+		options := DefaultOptions()
+		options.Heuristic = HeuristicKind(formula % 5)
+		options.Restart = RestartKind(formula % 5)
+		options.RestartInterval = 2
+		options.ReduceInterval = 3
+
+		for name, create := range sequentialSolvers(numVars, clauses[:half], options) {
+			s, result := create()
+			known := append([][]Lit{}, clauses[:half]...)
+			added := half
+			for round := 0; round < 4; round++ {
+				// Add some of the remaining clauses between the calls
+				for k := 0; round > 0 && k < 2 && added < len(clauses); k++ {
+					s.AddClause(clauses[added]...)
+					known = append(known, clauses[added])
+					added++
+				}
+				assumptions := make([]Lit, 0)
+				for k := 0; k < r.Intn(4); k++ {
+					assumptions = append(assumptions, MkLit(1+r.Intn(numVars), r.Intn(2) == 0))
+				}
+
+				s.Solve(context.Background(), assumptions...)
+				want := bruteForce(numVars, known, assumptions)
+				switch {
+				case result() == UNKNOWN:
+					t.Fatalf("%s: formula %d round %d: result %s", name, formula, round, result())
+				case (result() == SATISFIABLE) != want:
+					t.Fatalf("%s: formula %d round %d: result %s, brute force says satisfiable: %v", name, formula, round, result(), want)
+				case want && !modelSatisfies(s.Model(), known, assumptions):
+					t.Fatalf("%s: formula %d round %d: model %v does not satisfy the clauses %v under %v", name, formula, round, s.Model(), known, assumptions)
+				case !want:
+					checkFailedAssumptions(t, numVars, known, assumptions, s.FailedAssumptions())
+				}
+			}
+		}
+	}
+}
+
+func TestFailedAssumptionsOnExample(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uf20-91")[0])
+	db := NewClauseDB(task)
+	clauses := make([][]Lit, db.Len())
+	for i := range clauses {
+		clauses[i] = append([]Lit{}, db.Lits(i)...)
+	}
+
+	for name, create := range sequentialSolvers(task.NumVars, clauses, DefaultOptions()) {
+		s, result := create()
+		s.Solve(context.Background())
+		if result() != SATISFIABLE {
+			t.Fatalf("%s: result %s without assumptions", name, result())
+		}
+		model := s.Model()
+
+		// Assuming the opposite of the whole model leaves no model, but the
+		// next call without assumptions is satisfiable again
+		assumptions := make([]Lit, len(model))
+		for i, lit := range model {
+			assumptions[i] = lit.Not()
+		}
+		s.Solve(context.Background(), assumptions...)
+		if result() == SATISFIABLE {
+			if !modelSatisfies(s.Model(), clauses, assumptions) {
+				t.Errorf("%s: model does not satisfy the clauses and assumptions", name)
+			}
+		} else {
+			if result() != UNSATISFIABLE {
+				t.Fatalf("%s: result %s under assumptions", name, result())
+			}
+			failed := s.FailedAssumptions()
+			for _, lit := range failed {
+				if !slices.Contains(assumptions, lit) {
+					t.Errorf("%s: failed assumption %s is not assumed", name, lit)
+				}
+			}
+			// The failed assumptions on their own have to be refuted by a fresh solver
+			fresh := NewCDCLSolver(task, DefaultOptions())
+			fresh.Solve(context.Background(), failed...)
+			if fresh.Result != UNSATISFIABLE {
+				t.Errorf("%s: failed assumptions %v are %s", name, failed, fresh.Result)
+			}
+		}
+
+		s.Solve(context.Background())
+		if result() != SATISFIABLE {
+			t.Errorf("%s: result %s after the assumptions were dropped", name, result())
+		}
+	}
+}
+
+func TestCDCLFailedAssumptionsLeaveOutIrrelevantOnes(t *testing.T) {
+	// 1 -> 2 -> -3, assumption 4 plays no part
+	clauses := [][]Lit{{neg(1), pos(2)}, {neg(2), neg(3)}}
+	s := NewCDCLSolver(taskFromLits(4, clauses), DefaultOptions())
+	s.Solve(context.Background(), pos(4), pos(1), pos(3))
+	if s.Result != UNSATISFIABLE {
+		t.Fatalf("result %s, want %s", s.Result, UNSATISFIABLE)
+	}
+	failed := s.FailedAssumptions()
+	slices.Sort(failed)
+	if want := []Lit{pos(1), pos(3)}; !slices.Equal(failed, want) {
+		t.Errorf("failed assumptions %v, want %v", failed, want)
+	}
+}
+
+func TestPropagatorRepropagatesLevelZeroAfterBacktracking(t *testing.T) {
+	task := taskFromLits(4, [][]Lit{{neg(1), pos(2)}, {neg(2), pos(3)}})
+	p := newPropagator(NewClauseDB(task))
+
+	// A clause added on level 0 is assigned, but only propagated once the
+	// search opened a level
+	p.addClause([]Lit{pos(1)})
+	p.newDecisionLevel()
+	p.assign(pos(4), -1)
+	p.propagate()
+	if p.levels[3] != 1 {
+		t.Fatalf("variable 3 assigned on level %d, want 1", p.levels[3])
+	}
+
+	// Its implications are undone with the level and have to come back
+	p.cancelUntil(0)
+	if conflict := p.propagate(); conflict >= 0 {
+		t.Fatalf("unexpected conflict %d", conflict)
+	}
+	for _, v := range []int{2, 3} {
+		if p.Value(pos(v)) != 1 || p.levels[v] != 0 {
+			t.Errorf("variable %d not implied on level 0 again", v)
+		}
+	}
+}
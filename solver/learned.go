@@ -0,0 +1,165 @@
+package solver
+
+import (
+	"sort"
+)
+
+type clauseTier int
+
+const (
+	tierLocal clauseTier = iota // Half of them is deleted by activity on every reduction
+	tier2                       // Kept as long as they take part in conflicts
+	tierCore                    // Kept forever
+)
+
+const (
+	coreMaxLBD  = 2
+	tier2MaxLBD = 6
+
+	clauseDecay   = 0.999
+	clauseRescale = 1e20
+
+	// reduceIncrement is added to the reduce interval after every reduction,
+	// so the database may slowly grow on long runs
+	reduceIncrement = 300
+)
+
+func tierForLBD(lbd int) clauseTier {
+	switch {
+	case lbd <= coreMaxLBD:
+		return tierCore
+	case lbd <= tier2MaxLBD:
+		return tier2
+	default:
+		return tierLocal
+	}
+}
+
+// learnedClause is the bookkeeping of a single learned clause
+type learnedClause struct {
+	id       int // Index of the clause in the database
+	lbd      int
+	activity float64
+	tier     clauseTier
+	used     bool // Took part in a conflict since the last reduction
+}
+
+// learnedClauses manages the clauses learned by the CDCL solver. Every clause
+// is put into a tier by its literal block distance: core clauses are kept
+// forever, tier2 clauses as long as they keep taking part in conflicts and
+// local clauses only while their activity is in the better half. A clause
+// whose LBD drops when it takes part in a conflict is promoted.
+type learnedClauses struct {
+	clauses   []learnedClause // Ordered by clause index
+	positions []int           // Per clause index: position in clauses, -1 for problem clauses
+	increment float64         // Activity added on every bump, grows to decay older bumps
+}
+
+func newLearnedClauses() *learnedClauses {
+	return &learnedClauses{
+		increment: 1,
+	}
+}
+
+// add registers a clause that was just learned
+func (l *learnedClauses) add(clauseID int, lbd int) {
+	for len(l.positions) <= clauseID {
+		l.positions = append(l.positions, -1)
+	}
+	l.positions[clauseID] = len(l.clauses)
+	l.clauses = append(l.clauses, learnedClause{
+		id:       clauseID,
+		lbd:      lbd,
+		activity: l.increment,
+		tier:     tierForLBD(lbd),
+	})
+}
+
+// get returns the bookkeeping of a clause, or nil for problem clauses
+func (l *learnedClauses) get(clauseID int) *learnedClause {
+	if clauseID >= len(l.positions) || l.positions[clauseID] < 0 {
+		return nil
+	}
+	return &l.clauses[l.positions[clauseID]]
+}
+
+// bump marks a clause as used in conflict analysis, with the LBD it has under
+// the current assignment
+func (l *learnedClauses) bump(clauseID int, lbd int) {
+	clause := l.get(clauseID)
+	if clause == nil {
+		return
+	}
+
+	clause.used = true
+	clause.activity += l.increment
+	if clause.activity > clauseRescale {
+		for i := range l.clauses {
+			l.clauses[i].activity /= clauseRescale
+		}
+		l.increment /= clauseRescale
+	}
+
+	if lbd < clause.lbd {
+		clause.lbd = lbd
+		clause.tier = max(clause.tier, tierForLBD(lbd))
+	}
+}
+
+// decay lowers the weight of all previous bumps, called once per conflict
+func (l *learnedClauses) decay() {
+	l.increment /= clauseDecay
+}
+
+// reduce deletes the less useful half of the local clauses from the database
+// and demotes the tier2 clauses which were not used since the last reduction.
+// Locked clauses, i.e. reasons of current assignments, are kept. The database
+// has to be compacted afterwards. It returns the number of deleted clauses.
+func (l *learnedClauses) reduce(db *ClauseDB, locked func(clauseID int) bool) int {
+	candidates := make([]int, 0)
+	for i := range l.clauses {
+		clause := &l.clauses[i]
+		if clause.tier == tierLocal && !locked(clause.id) {
+			candidates = append(candidates, i)
+		}
+		if clause.tier == tier2 && !clause.used {
+			clause.tier = tierLocal
+		}
+		clause.used = false
+	}
+
+	// Least active first, ties delete the clause spanning more levels
+	sort.Slice(candidates, func(a, b int) bool {
+		ca, cb := l.clauses[candidates[a]], l.clauses[candidates[b]]
+		if ca.activity != cb.activity {
+			return ca.activity < cb.activity
+		}
+		return ca.lbd > cb.lbd
+	})
+
+	deleted := candidates[:len(candidates)/2]
+	for _, i := range deleted {
+		db.Delete(l.clauses[i].id)
+	}
+	return len(deleted)
+}
+
+// relocate drops the deleted clauses and updates the indices of the others
+// after the database was compacted
+func (l *learnedClauses) relocate(remap []int, numClauses int) {
+	l.positions = make([]int, numClauses)
+	for i := range l.positions {
+		l.positions[i] = -1
+	}
+
+	kept := l.clauses[:0]
+	for _, clause := range l.clauses {
+		if remap[clause.id] < 0 {
+			continue
+		}
+		clause.id = remap[clause.id]
+		l.positions[clause.id] = len(kept)
+		kept = append(kept, clause)
+	}
+	l.clauses = kept
+}
@@ -0,0 +1,107 @@
+package solver
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTierForLBD(t *testing.T) {
+	tests := []struct {
+		lbd  int
+		want clauseTier
+	}{
+		{1, tierCore},
+		{2, tierCore},
+		{3, tier2},
+		{6, tier2},
+		{7, tierLocal},
+		{30, tierLocal},
+	}
+	for _, tt := range tests {
+		if tier := tierForLBD(tt.lbd); tier != tt.want {
+			t.Errorf("tierForLBD(%d) = %d, want %d", tt.lbd, tier, tt.want)
+		}
+	}
+}
+
+func TestLearnedClausePromotion(t *testing.T) {
+	l := newLearnedClauses()
+	l.add(0, 9)
+	clause := l.get(0)
+	if clause.tier != tierLocal {
+		t.Fatalf("clause with LBD 9 in tier %d", clause.tier)
+	}
+
+	// The LBD under the current assignment only ever lowers the tier number
+	// if it improves, a worse one is ignored
+	steps := []struct {
+		lbd      int
+		wantLBD  int
+		wantTier clauseTier
+	}{
+		{8, 8, tierLocal},
+		{4, 4, tier2},
+		{7, 4, tier2},
+		{2, 2, tierCore},
+		{5, 2, tierCore},
+	}
+	activity := clause.activity
+	for _, step := range steps {
+		l.bump(0, step.lbd)
+		if clause.lbd != step.wantLBD || clause.tier != step.wantTier {
+			t.Errorf("bump with LBD %d: LBD %d in tier %d, want %d in tier %d", step.lbd, clause.lbd, clause.tier, step.wantLBD, step.wantTier)
+		}
+		if clause.activity <= activity || !clause.used {
+			t.Errorf("bump with LBD %d: activity %v not raised or not marked as used", step.lbd, clause.activity)
+		}
+		activity = clause.activity
+	}
+}
+
+func TestLearnedClausesReduce(t *testing.T) {
+	// Two original clauses, followed by learned ones with these LBDs
+	db := NewClauseDB(taskFromLits(9, [][]Lit{{pos(1), pos(2)}, {neg(1), pos(3)}}))
+	l := newLearnedClauses()
+	lbds := []int{9, 9, 9, 10, 5, 2}
+	for i, lbd := range lbds {
+		l.add(db.AddLearned([]Lit{pos(i + 1), neg(i + 2), pos(9)}), lbd)
+	}
+	// Clause 2 is the reason of an assignment, 3 was used in a conflict
+	l.bump(3, 9)
+	locked := func(clauseID int) bool { return clauseID == 2 }
+
+	// Of the local clauses 3, 4 and 5 the least active one goes, the tie
+	// between 4 and 5 deletes the one spanning more levels
+	if deleted := l.reduce(db, locked); deleted != 1 {
+		t.Fatalf("deleted %d clauses, want 1", deleted)
+	}
+	for clauseID := range db.Len() {
+		if db.IsDeleted(clauseID) != (clauseID == 5) {
+			t.Errorf("clause %d deleted: %v", clauseID, db.IsDeleted(clauseID))
+		}
+	}
+
+	// The unused tier2 clause is demoted, nothing counts as used anymore
+	remap := db.Compact()
+	if want := []int{0, 1, 2, 3, 4, -1, 5, 6}; !slices.Equal(remap, want) {
+		t.Fatalf("remap %v, want %v", remap, want)
+	}
+	l.relocate(remap, db.Len())
+	if len(l.clauses) != len(lbds)-1 {
+		t.Errorf("%d learned clauses left, want %d", len(l.clauses), len(lbds)-1)
+	}
+	if l.get(1) != nil {
+		t.Error("problem clause 1 has learned clause bookkeeping")
+	}
+	if clause := l.get(5); clause.lbd != 5 || clause.tier != tierLocal {
+		t.Errorf("former clause 6 has LBD %d in tier %d, want LBD 5 demoted to local", clause.lbd, clause.tier)
+	}
+	if clause := l.get(6); clause.tier != tierCore {
+		t.Errorf("core clause moved to tier %d", clause.tier)
+	}
+	for i, clause := range l.clauses {
+		if clause.used {
+			t.Errorf("learned clause %d still marked as used", i)
+		}
+	}
+}
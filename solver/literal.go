@@ -0,0 +1,76 @@
+package solver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// Lit is a literal packed into an integer: the variable ID times two, plus
+// one if the variable is negated. Negating a literal flips the lowest bit and
+// literals can index per-literal tables directly.
+type Lit uint32
+
+func MkLit(id int, negated bool) Lit {
+	if negated {
+		return Lit(2*id + 1)
+	}
+	return Lit(2 * id)
+}
+
+// LitFromVariable converts a parsed variable to a literal
+func LitFromVariable(v parser.Variable) Lit {
+	return MkLit(v.ID, v.Negated)
+}
+
+// Var returns the variable ID of the literal
+func (l Lit) Var() int {
+	return int(l >> 1)
+}
+
+func (l Lit) Negated() bool {
+	return l&1 == 1
+}
+
+// Not returns the opposite literal of the same variable
+func (l Lit) Not() Lit {
+	return l ^ 1
+}
+
+// Variable converts the literal back to a parsed variable
+func (l Lit) Variable() parser.Variable {
+	return parser.Variable{
+		ID:      l.Var(),
+		Negated: l.Negated(),
+	}
+}
+
+func (l Lit) String() string {
+	if l.Negated() {
+		return fmt.Sprintf("-%d", l.Var())
+	}
+	return fmt.Sprintf("%d", l.Var())
+}
+
+// ClauseFromLits converts literals to a clause of parsed variables, e.g. to
+// report a solution
+func ClauseFromLits(lits []Lit) *parser.Clause {
+	clause := &parser.Clause{
+		Vars: make([]parser.Variable, len(lits)),
+	}
+	for i, lit := range lits {
+		clause.Vars[i] = lit.Variable()
+	}
+	return clause
+}
+
+// modelFromTrail copies the assigned literals ordered by variable ID
+func modelFromTrail(trail []Lit) []Lit {
+	model := make([]Lit, len(trail))
+	copy(model, trail)
+	sort.Slice(model, func(i, j int) bool {
+		return model[i].Var() < model[j].Var()
+	})
+	return model
+}
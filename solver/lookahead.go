@@ -0,0 +1,215 @@
+package solver
+
+import (
+	"math"
+	"sort"
+)
+
+// lookaheadCandidates is the number of variables tried per node, the ones
+// occurring most often in open clauses
+const lookaheadCandidates = 16
+
+// lookOutcome is what a lookahead found out about the current assignment
+type lookOutcome int
+
+const (
+	lookBranch   lookOutcome = iota // The returned literal is the best one to split on
+	lookSolved                      // Every clause is satisfied
+	lookConflict                    // Both polarities of a variable failed, propagating the assignment runs into a conflict
+)
+
+// lookahead scores split variables by trying them out: both polarities of a
+// candidate are assigned on a decision level of their own and propagated,
+// and the clauses shortened by that are counted. A polarity leading to a
+// conflict is a failed literal, so the opposite one is implied.
+type lookahead struct {
+	engine     *propagator
+	candidates int     // Variables tried per node
+	occurs     [][]int // Per literal: the clauses it occurs in
+	numClauses int     // Clauses covered by the occurrence lists
+	stamps     []int   // Per clause: last reduction that counted it
+	stamp      int
+	onForce    func(lit Lit) // Called before the negation of a failed literal is assigned, nil if nobody needs it
+
+	Lookaheads     int // Polarities tried
+	FailedLiterals int // Polarities that led to a conflict
+}
+
+func newLookahead(engine *propagator, candidates int) *lookahead {
+	return &lookahead{
+		engine:     engine,
+		candidates: candidates,
+	}
+}
+
+// look tries the candidate variables under the current, fully propagated
+// assignment. The negations of failed literals are assigned on the current
+// decision level and returned, the candidates are tried again after that
+// since the reductions changed. If both polarities of a variable fail, one of
+// them is assigned without propagating it, so the caller finds the conflict
+// by propagating. The branch literal is the polarity of the best variable
+// that shortens fewer clauses, it is the more likely one to be satisfiable.
+func (la *lookahead) look() (Lit, []Lit, lookOutcome) {
+	la.index()
+	implied := make([]Lit, 0)
+	for {
+		candidates := la.pickCandidates()
+		if len(candidates) == 0 {
+			return 0, implied, lookSolved
+		}
+
+		best, bestScore := Lit(0), math.Inf(-1)
+		failed := false
+		for _, id := range candidates {
+			pos, neg := MkLit(id, false), MkLit(id, true)
+			if la.engine.Value(pos) != 0 {
+				// Implied by a failed literal of this round
+				continue
+			}
+			posScore, posOK := la.try(pos)
+			negScore, negOK := la.try(neg)
+			if !posOK || !negOK {
+				forced := neg
+				if !negOK {
+					forced = pos
+				}
+				if la.onForce != nil {
+					la.onForce(forced)
+				}
+				implied = append(implied, forced)
+				la.engine.assign(forced, -1)
+				if !posOK && !negOK {
+					return 0, implied, lookConflict
+				}
+				// Propagates the same as the successful try
+				la.engine.propagate()
+				failed = true
+				continue
+			}
+
+			// Variables shortening many clauses in both polarities are preferred
+			score := 1024*posScore*negScore + posScore + negScore
+			if score > bestScore {
+				bestScore = score
+				best = pos
+				if negScore < posScore {
+					best = neg
+				}
+			}
+		}
+		if !failed {
+			return best, implied, lookBranch
+		}
+	}
+}
+
+// try assigns a literal on a new decision level, propagates it and undoes it
+// again. It returns the reduction, or false if the literal failed. Undoing the
+// probe does not notify the heuristic, the phases it saved stay those of the
+// search; the probed variables were unassigned before, so the heuristic still
+// has them as open.
+func (la *lookahead) try(lit Lit) (float64, bool) {
+	la.Lookaheads++
+	level, start := la.engine.decisionLevel(), len(la.engine.trail)
+	la.engine.newDecisionLevel()
+	la.engine.assign(lit, -1)
+	defer func(listener BacktrackListener) {
+		la.engine.listener = nil
+		la.engine.cancelUntil(level)
+		la.engine.listener = listener
+	}(la.engine.listener)
+
+	if la.engine.propagate() >= 0 {
+		la.FailedLiterals++
+		return 0, false
+	}
+	return la.reduction(start), true
+}
+
+// reduction weighs the open clauses shortened by the assignments from the
+// given trail position on. A clause that became binary counts 1, every
+// literal it has left beyond that divides its weight by 5.
+func (la *lookahead) reduction(start int) float64 {
+	la.stamp++
+	reduction := 0.0
+	for _, assigned := range la.engine.trail[start:] {
+		for _, clauseID := range la.occurs[assigned.Not()] {
+			if la.stamps[clauseID] == la.stamp {
+				continue
+			}
+			la.stamps[clauseID] = la.stamp
+
+			unassigned, satisfied := 0, false
+			for _, lit := range la.engine.db.Lits(clauseID) {
+				switch la.engine.Value(lit) {
+				case 1:
+					satisfied = true
+				case 0:
+					unassigned++
+				}
+				if satisfied {
+					break
+				}
+			}
+			if !satisfied {
+				reduction += math.Pow(0.2, float64(unassigned-2))
+			}
+		}
+	}
+	return reduction
+}
+
+// pickCandidates returns the unassigned variables occurring most often in
+// open clauses, at most the configured number of them
+func (la *lookahead) pickCandidates() []int {
+	counts := make([]int, la.engine.NumVars()+1)
+	forEachOpenClause(la.engine, func(unassigned []Lit) {
+		for _, lit := range unassigned {
+			counts[lit.Var()]++
+		}
+	})
+
+	candidates := make([]int, 0)
+	for id, count := range counts {
+		if count > 0 {
+			candidates = append(candidates, id)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return counts[candidates[i]] > counts[candidates[j]]
+	})
+	if len(candidates) > la.candidates {
+		candidates = candidates[:la.candidates]
+	}
+	return candidates
+}
+
+// memory estimates the bytes held by the occurrence lists
+func (la *lookahead) memory() int64 {
+	memory := int64(cap(la.stamps)) * intBytes
+	for _, clauses := range la.occurs {
+		memory += sliceBytes + int64(cap(clauses))*intBytes
+	}
+	return memory
+}
+
+// reindex drops the occurrence lists after the clauses were compacted, the
+// next lookahead builds them again
+func (la *lookahead) reindex() {
+	la.numClauses = -1
+}
+
+// index builds the occurrence lists, again whenever clauses were added
+func (la *lookahead) index() {
+	if la.numClauses == la.engine.db.Len() && len(la.occurs) == 2*la.engine.NumVars()+2 {
+		return
+	}
+	la.numClauses = la.engine.db.Len()
+	la.occurs = make([][]int, 2*la.engine.NumVars()+2)
+	for clauseID := 0; clauseID < la.numClauses; clauseID++ {
+		for _, lit := range la.engine.db.Lits(clauseID) {
+			la.occurs[lit] = append(la.occurs[lit], clauseID)
+		}
+	}
+	la.stamps = make([]int, la.numClauses)
+}
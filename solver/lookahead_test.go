@@ -0,0 +1,107 @@
+package solver
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLookaheadSolvesExamples(t *testing.T) {
+	tests := []struct {
+		folder string
+		want   Result
+	}{
+		{"uf20-91", SATISFIABLE},
+		{"uf50-218", SATISFIABLE},
+		{"uuf50-218", UNSATISFIABLE},
+	}
+	failedLiterals := 0
+	for _, tt := range tests {
+		for _, file := range exampleFiles(t, tt.folder) {
+			task := loadTask(t, file)
+			s := NewLookaheadSolver(task, DefaultOptions())
+			s.Solve(context.Background())
+			if s.Result != tt.want {
+				t.Fatalf("%s: result %s, want %s", filepath.Base(file), s.Result, tt.want)
+			}
+			if s.Result == SATISFIABLE {
+				if err := Verify(task, s.Solution); err != nil {
+					t.Errorf("%s: solution rejected: %v", filepath.Base(file), err)
+				}
+			}
+			failedLiterals += s.Stats.FailedLiterals
+		}
+	}
+	if failedLiterals == 0 {
+		t.Error("no failed literals found on any example")
+	}
+}
+
+func TestLookaheadFailedLiteral(t *testing.T) {
+	// 1 fails since it implies both 2 and -2
+	task := taskFromLits(5, [][]Lit{
+		{neg(1), pos(2)}, {neg(1), neg(2)},
+		{pos(3), pos(4), pos(5)}, {neg(3), pos(4), pos(5)}, {pos(1), neg(4), pos(5)},
+	})
+	engine := newPropagator(NewClauseDB(task))
+	la := newLookahead(engine, lookaheadCandidates)
+
+	branch, implied, outcome := la.look()
+	if outcome == lookConflict {
+		t.Fatal("lookahead refuted a satisfiable formula")
+	}
+	if !slices.Contains(implied, neg(1)) || engine.Value(neg(1)) != 1 {
+		t.Errorf("failed literal 1 not refuted: implied %v", implied)
+	}
+	if la.FailedLiterals == 0 {
+		t.Error("failed literal not counted")
+	}
+	if outcome == lookBranch && engine.Value(branch) != 0 {
+		t.Errorf("branch %s is not an open literal", branch)
+	}
+	if engine.decisionLevel() != 0 {
+		t.Errorf("lookahead left decision level %d open", engine.decisionLevel())
+	}
+}
+
+func TestLookaheadOutcomes(t *testing.T) {
+	// Both polarities of 1 fail
+	conflicting := taskFromLits(3, [][]Lit{{neg(1), pos(2)}, {neg(1), neg(2)}, {pos(1), pos(3)}, {pos(1), neg(3)}})
+	if _, _, outcome := newLookahead(newPropagator(NewClauseDB(conflicting)), lookaheadCandidates).look(); outcome != lookConflict {
+		t.Errorf("outcome %d on an unsatisfiable formula, want a conflict", outcome)
+	}
+
+	engine := newPropagator(NewClauseDB(taskFromLits(2, [][]Lit{{pos(1), pos(2)}})))
+	decide(engine, pos(1))
+	if _, _, outcome := newLookahead(engine, lookaheadCandidates).look(); outcome != lookSolved {
+		t.Errorf("outcome %d with every clause satisfied, want solved", outcome)
+	}
+}
+
+func TestLookaheadKeepsSavedPhases(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uf50-218")[0])
+	engine := newPropagator(NewClauseDB(task))
+	heuristic := newVSIDS(task.NumVars)
+	engine.attachHeuristic(heuristic)
+
+	// Save some phases the way a search would
+	decide(engine, pos(1), neg(2), pos(3))
+	engine.propagate()
+	engine.cancelUntil(0)
+	phases := slices.Clone(heuristic.phase)
+
+	la := newLookahead(engine, lookaheadCandidates)
+	if _, _, outcome := la.look(); outcome == lookConflict {
+		t.Fatal("lookahead refuted a satisfiable example")
+	}
+	if la.Lookaheads == 0 {
+		t.Fatal("no literals probed")
+	}
+	if !slices.Equal(heuristic.phase, phases) {
+		t.Error("probing changed the saved phases")
+	}
+	if engine.listener != BacktrackListener(heuristic) {
+		t.Error("the heuristic is no longer notified about backtracking")
+	}
+}
@@ -0,0 +1,51 @@
+package solver
+
+import "context"
+
+// Options configures the search of the solvers
+type Options struct {
+	Heuristic       HeuristicKind // Branching heuristic used to pick split variables
+	Seed            int64         // Seed for randomized decisions
+	Polarity        Polarity      // Polarity of the decisions, by default the one the heuristic picks
+	Restart         RestartKind   // Restart policy of the CDCL solver
+	RestartInterval int           // Conflicts between restarts (fixed), Luby unit, first interval (geometric) or LBD window (glucose)
+	RestartFactor   float64       // Growth of the interval after every geometric restart
+	ReduceInterval  int           // Learned clauses before the first reduction of the CDCL clause database, or imported clauses between reductions of a parallel worker, 0 to keep all
+	MaxDecisions    int           // Decisions after which Solve gives up with UNKNOWN, 0 for no limit
+	MaxConflicts    int           // Conflicts after which Solve gives up with UNKNOWN, 0 for no limit
+	ShareSize       int           // Longest learned clause the parallel workers pass to each other, 0 to share none
+	ShareLBD        int           // Most decision levels the literals of a shared clause may span
+	MemLimit        int64         // Estimated bytes of queued work items, checkpoints and worker clauses after which the parallel solver gives up with UNKNOWN, 0 for no limit
+}
+
+// DefaultOptions returns the options used when nothing else is configured
+func DefaultOptions() Options {
+	return Options{
+		Heuristic:       DLCS,
+		Seed:            1,
+		Polarity:        HeuristicPolarity,
+		Restart:         NoRestarts,
+		RestartInterval: 100,
+		RestartFactor:   1.5,
+		ReduceInterval:  2000,
+		ShareSize:       8,
+		ShareLBD:        4,
+	}
+}
+
+// exhausted reports whether the given counters of a search used up one of
+// the budgets
+func (o Options) exhausted(decisions, conflicts int) bool {
+	return o.MaxDecisions > 0 && decisions >= o.MaxDecisions ||
+		o.MaxConflicts > 0 && conflicts >= o.MaxConflicts
+}
+
+// cancelled reports whether the context of a search is done, without blocking
+func cancelled(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,118 @@
+package solver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// stoppedHeuristic holds every worker of a parallel solver in its first
+// decision until the solver stops them, so only the context or a budget can
+// end the search
+type stoppedHeuristic struct {
+	BranchingHeuristic
+	ps *ParallelSolver
+}
+
+func (h stoppedHeuristic) PickBranch(state SearchState) (Lit, bool) {
+	<-h.ps.doneChan
+	return h.BranchingHeuristic.PickBranch(state)
+}
+
+func TestOptionsExhausted(t *testing.T) {
+	tests := []struct {
+		maxDecisions, maxConflicts int
+		decisions, conflicts       int
+		want                       bool
+	}{
+		{0, 0, 1000, 1000, false},
+		{10, 0, 9, 1000, false},
+		{10, 0, 10, 0, true},
+		{0, 5, 1000, 4, false},
+		{0, 5, 0, 5, true},
+		{10, 5, 3, 7, true},
+	}
+	for _, tt := range tests {
+		o := Options{MaxDecisions: tt.maxDecisions, MaxConflicts: tt.maxConflicts}
+		if got := o.exhausted(tt.decisions, tt.conflicts); got != tt.want {
+			t.Errorf("MaxDecisions %d, MaxConflicts %d: exhausted(%d, %d) = %t, want %t",
+				tt.maxDecisions, tt.maxConflicts, tt.decisions, tt.conflicts, got, tt.want)
+		}
+	}
+}
+
+// budgetSolvers runs each solver on a task and returns its result
+var budgetSolvers = map[string]func(ctx context.Context, task *parser.Task, options Options) Result{
+	"dpll": func(ctx context.Context, task *parser.Task, options Options) Result {
+		s := NewSolver(task, options)
+		s.Solve(ctx)
+		return s.Result
+	},
+	"lookahead": func(ctx context.Context, task *parser.Task, options Options) Result {
+		s := NewLookaheadSolver(task, options)
+		s.Solve(ctx)
+		return s.Result
+	},
+	"cdcl": func(ctx context.Context, task *parser.Task, options Options) Result {
+		s := NewCDCLSolver(task, options)
+		s.Solve(ctx)
+		return s.Result
+	},
+	"parallel": func(ctx context.Context, task *parser.Task, options Options) Result {
+		result, _ := NewParallelSolver(task, 2, 0, false, options).Solve(ctx)
+		return result
+	},
+}
+
+func TestBudgetsStopSolvers(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+	budgets := map[string]func(*Options){
+		"decisions": func(o *Options) { o.MaxDecisions = 3 },
+		"conflicts": func(o *Options) { o.MaxConflicts = 2 },
+	}
+	for name, solve := range budgetSolvers {
+		for budget, set := range budgets {
+			options := DefaultOptions()
+			set(&options)
+			if result := solve(context.Background(), task, options); result != UNKNOWN {
+				t.Errorf("%s with a %s budget: result %s, want %s", name, budget, result, UNKNOWN)
+			}
+		}
+
+		// A budget the search does not reach does not change the result
+		options := DefaultOptions()
+		options.MaxDecisions, options.MaxConflicts = 1000000, 1000000
+		if result := solve(context.Background(), task, options); result != UNSATISFIABLE {
+			t.Errorf("%s with large budgets: result %s, want %s", name, result, UNSATISFIABLE)
+		}
+	}
+}
+
+func TestSolversStopOnCancelledContext(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The parallel solver may finish before it notices, see below
+	for _, name := range []string{"dpll", "lookahead", "cdcl"} {
+		if result := budgetSolvers[name](ctx, task, DefaultOptions()); result != UNKNOWN {
+			t.Errorf("%s: result %s, want %s", name, result, UNKNOWN)
+		}
+	}
+}
+
+func TestParallelSolverStopsWhenContextIsCancelled(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uf50-218")[0])
+	ps := NewParallelSolver(task, 2, 0, false, DefaultOptions())
+	ps.reset()
+	for id, heuristic := range ps.heuristics {
+		ps.heuristics[id] = stoppedHeuristic{heuristic, ps}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result, solution := ps.Solve(ctx); result != UNKNOWN || solution != nil {
+		t.Errorf("result %s with solution %v, want %s", result, solution, UNKNOWN)
+	}
+}
@@ -1,93 +1,120 @@
 package solver
 
 import (
-	"fmt"
+	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/CptPie/DLPP-solver/logger"
 	"github.com/CptPie/DLPP-solver/parser"
 )
 
-// WorkItem represents a state in the search tree that needs to be explored
+// WorkItem represents a state in the search tree that needs to be explored.
+// Only the assignments leading to the state are stored, the worker replays
+// them on its own propagator instead of receiving a copy of the clauses.
 type WorkItem struct {
-	WorkCopy []*parser.Clause
-	Solution *parser.Clause
-	Depth    int // Track depth for limiting parallelization
+	Path  []Lit // Assignments leading to this state
+	Depth int   // Track depth for limiting parallelization
 }
 
-// WorkQueue is a thread-safe queue for work items
-type WorkQueue struct {
-	items  []*WorkItem
-	mu     sync.Mutex
-	cond   *sync.Cond
-	closed bool
+// Rough sizes for the memory estimate of the parallel solver
+const (
+	workItemBytes    = 64 // WorkItem struct, slice header and the pointer in the queue
+	checkpointBytes  = 16 // Checkpoint struct and the pointer on the stack
+	litBytes         = 4
+	intBytes         = 8
+	sliceBytes       = 24 // Header of a slice in a slice, e.g. a watch list
+	clauseHeaderSize = 12 // clauseHeader in the arena of a ClauseDB
+)
+
+// importInterval is the number of backtracks after which a worker imports
+// the clauses of the others during a work item
+const importInterval = 64
+
+// size estimates the bytes a queued work item holds
+func (item *WorkItem) size() int64 {
+	return workItemBytes + int64(cap(item.Path))*litBytes
+}
+
+// WorkDeque holds the work items of one worker. The owner pushes and pops
+// at the bottom, so it continues with its deepest branch, while idle workers
+// steal from the top, where the oldest and shallowest items are.
+type WorkDeque struct {
+	items []*WorkItem
+	mu    sync.Mutex
 }
 
-func NewWorkQueue() *WorkQueue {
-	wq := &WorkQueue{
+func NewWorkDeque() *WorkDeque {
+	return &WorkDeque{
 		items: make([]*WorkItem, 0),
 	}
-	wq.cond = sync.NewCond(&wq.mu)
-	return wq
 }
 
-func (wq *WorkQueue) Push(item *WorkItem) {
-	wq.mu.Lock()
-	defer wq.mu.Unlock()
-	wq.items = append(wq.items, item)
-	wq.cond.Signal() // Wake up one waiting worker
+// Push adds an item at the bottom, only the owner pushes
+func (wd *WorkDeque) Push(item *WorkItem) {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	wd.items = append(wd.items, item)
 }
 
-func (wq *WorkQueue) Pop() *WorkItem {
-	wq.mu.Lock()
-	defer wq.mu.Unlock()
-
-	// Block while queue is empty and not closed
-	for len(wq.items) == 0 && !wq.closed {
-		wq.cond.Wait()
-	}
-
-	// Return nil if queue is empty (after waking from close)
-	if len(wq.items) == 0 {
+// Pop removes the newest item from the bottom, nil if the deque is empty
+func (wd *WorkDeque) Pop() *WorkItem {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	if len(wd.items) == 0 {
 		return nil
 	}
-
-	item := wq.items[len(wq.items)-1]
-	wq.items = wq.items[:len(wq.items)-1]
+	item := wd.items[len(wd.items)-1]
+	wd.items[len(wd.items)-1] = nil
+	wd.items = wd.items[:len(wd.items)-1]
 	return item
 }
 
-func (wq *WorkQueue) Len() int {
-	wq.mu.Lock()
-	defer wq.mu.Unlock()
-	return len(wq.items)
+// Steal removes the oldest item from the top, nil if the deque is empty
+func (wd *WorkDeque) Steal() *WorkItem {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	if len(wd.items) == 0 {
+		return nil
+	}
+	item := wd.items[0]
+	wd.items[0] = nil
+	wd.items = wd.items[1:]
+	return item
 }
 
-// Close marks the queue as closed and wakes all waiting workers
-func (wq *WorkQueue) Close() {
-	wq.mu.Lock()
-	defer wq.mu.Unlock()
-	if !wq.closed {
-		wq.closed = true
-		wq.cond.Broadcast()
-	}
+func (wd *WorkDeque) Len() int {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	return len(wd.items)
 }
 
-// WakeAll wakes all waiting workers (e.g., to check termination conditions)
-func (wq *WorkQueue) WakeAll() {
-	wq.mu.Lock()
-	defer wq.mu.Unlock()
-	wq.cond.Broadcast()
+// Drain removes and returns all items
+func (wd *WorkDeque) Drain() []*WorkItem {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	items := wd.items
+	wd.items = make([]*WorkItem, 0)
+	return items
 }
 
-// ParallelSolver manages parallel SAT solving with work stealing
+// ParallelSolver manages parallel SAT solving with work stealing. The search
+// is exhausted once no work item is pending: every pushed item counts until
+// the worker that took it is done with it, and the items it split off are
+// pushed before that.
 type ParallelSolver struct {
 	Problem       *parser.Task
 	NumWorkers    int
 	ParallelDepth int  // 0 means unlimited, >0 means only parallelize up to this depth
 	OptimumMode   bool // If true, find minimal solution instead of stopping at first
+	Options       Options
+	Cubes         [][]Lit   // Cube-and-conquer: the work items to start with, nil to split the search while solving
+	Backend       Algorithm // Cube-and-conquer: solves the cubes, any algorithm but CDCL in optimum mode
+	Stats         Stats     // Counters of the last call of Solve, summed over the workers
 
-	workQueue     *WorkQueue
+	clauses       *ClauseDB    // Packed clauses of the problem, cloned by every worker
+	deques        []*WorkDeque // Per worker: its own work items, others steal from the top
 	resultChan    chan Result
 	solutionChan  chan *parser.Clause
 	doneChan      chan struct{}
@@ -96,24 +123,50 @@ type ParallelSolver struct {
 	foundSolution    bool
 	bestSolution     *parser.Clause
 	bestSolutionSize int
-	busyWorkers      int
 	lastWorkItem     *WorkItem // Last examined work item (useful for UNSAT debugging)
 	mu               sync.Mutex
 
-	maxQueueSize int // Maximum work items in queue to prevent memory explosion
+	maxQueueSize int          // Maximum work items queued by all workers together to prevent memory explosion
+	queued       atomic.Int64 // Work items in the deques
+	pending      atomic.Int64 // Work items queued or being processed, the search is exhausted at 0
+	sleepers     atomic.Int64 // Workers waiting for work
+	idleMu       sync.Mutex   // Guards waiting for work
+	idleCond     *sync.Cond   // Wakes waiting workers when work is pushed or the search ends
+
+	engines    []*propagator        // Per worker: propagator kept between calls of Solve
+	heuristics []BranchingHeuristic // Per worker: heuristic kept between calls of Solve
+	imports    []*importedClauses   // Per worker: clauses taken from the exchange, kept between calls of Solve
+	conquerors []*CDCLSolver        // Per worker: solver of the cubes with the CDCL backend, kept between calls of Solve
+	lookaheads []*lookahead         // Per worker: split variable picker of the lookahead backend
+	model      []Lit                // Assignment of the last satisfiable call, ordered by variable
+	failed     []Lit                // Assumptions of the last unsatisfiable call
+	stats      []Stats              // Per worker: counters of the current call of Solve
+	workItems  []WorkerStats        // Per worker: work items of the current call of Solve
+	decisions  atomic.Int64         // Decisions of all workers in the current call, for the budgets
+	conflicts  atomic.Int64         // Conflicts of all workers in the current call, for the budgets
+	open       [][]Lit              // Cubes the workers of the last call gave up when it was stopped
+
+	queueBytes  atomic.Int64   // Estimated bytes of the queued work items
+	workerBytes []atomic.Int64 // Per worker: estimated bytes of its clauses and propagation state
+	checkpoints []atomic.Int64 // Per worker: open checkpoints of the current work item
+	lowMemory   atomic.Bool    // Set once the estimate came near the memory limit, to report it only once
+	stopped     atomic.Bool    // Set by the first worker that finds a budget used up
+
+	exchange *clauseExchange // Passes learned clauses between the workers, nil if they share none
 }
 
-func NewParallelSolver(task *parser.Task, numWorkers int, parallelDepth int, optimum bool) *ParallelSolver {
+func NewParallelSolver(task *parser.Task, numWorkers int, parallelDepth int, optimum bool, options Options) *ParallelSolver {
 	return &ParallelSolver{
 		Problem:          task,
 		NumWorkers:       numWorkers,
 		ParallelDepth:    parallelDepth,
 		OptimumMode:      optimum,
-		workQueue:        NewWorkQueue(),
+		Options:          options,
+		clauses:          NewClauseDB(task),
 		resultChan:       make(chan Result, 1),
 		solutionChan:     make(chan *parser.Clause, 1),
 		doneChan:         make(chan struct{}),
-		maxQueueSize:     numWorkers * 4, // Limit queue to prevent exponential memory growth
+		maxQueueSize:     numWorkers * 4,     // Limit queued items to prevent exponential memory growth
 		bestSolutionSize: int(^uint(0) >> 1), // Max int value
 	}
 }
@@ -152,178 +205,466 @@ func (ps *ParallelSolver) GetBestSolution() (*parser.Clause, int) {
 	return ps.bestSolution, ps.bestSolutionSize
 }
 
-func (ps *ParallelSolver) IncrementBusyWorkers() {
+func (ps *ParallelSolver) SetLastWorkItem(item *WorkItem) {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
-	ps.busyWorkers++
+	ps.lastWorkItem = item
 }
 
-func (ps *ParallelSolver) DecrementBusyWorkers() {
+func (ps *ParallelSolver) GetLastWorkItem() *WorkItem {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
+	return ps.lastWorkItem
+}
 
-	ps.busyWorkers--
-
-	// Check termination conditions while holding the lock
-	if ps.busyWorkers == 0 && ps.workQueue.Len() == 0 {
-		if ps.OptimumMode || !ps.foundSolution {
-			// Close queue to wake all sleeping workers so they can check termination
-			ps.workQueue.Close()
+// OpenClauses replays the assignments of a work item and returns the clauses
+// left open by them, with falsified variables marked as impossible
+func (ps *ParallelSolver) OpenClauses(item *WorkItem) []*parser.Clause {
+	engine := newPropagator(ps.clauses.Clone())
+	for _, lit := range item.Path {
+		engine.reserveVars(lit.Var())
+		if engine.Value(lit) == 0 {
+			engine.assign(lit, -1)
 		}
 	}
+	engine.propagate()
+	return engine.openClauses()
 }
 
-func (ps *ParallelSolver) GetBusyWorkers() int {
-	ps.mu.Lock()
-	defer ps.mu.Unlock()
-	return ps.busyWorkers
+// AddClause adds a clause to the problem between calls of Solve. The workers
+// keep their heuristic state, the clause may also use new variables.
+func (ps *ParallelSolver) AddClause(lits ...Lit) {
+	ps.clauses.Add(lits)
+	for _, engine := range ps.engines {
+		engine.cancelUntil(0)
+		engine.addClause(lits)
+	}
+	for _, conqueror := range ps.conquerors {
+		conqueror.AddClause(lits...)
+	}
 }
 
-func (ps *ParallelSolver) SetLastWorkItem(item *WorkItem) {
-	ps.mu.Lock()
-	defer ps.mu.Unlock()
-	ps.lastWorkItem = item
+// Model returns the assignment found by the last call of Solve, ordered by
+// variable, or nil if it was not satisfiable
+func (ps *ParallelSolver) Model() []Lit {
+	return ps.model
 }
 
-func (ps *ParallelSolver) GetLastWorkItem() *WorkItem {
-	ps.mu.Lock()
-	defer ps.mu.Unlock()
-	return ps.lastWorkItem
+// FailedAssumptions returns the assumptions of the last call of Solve if it
+// was unsatisfiable. The workers do not analyse why their branches failed, so
+// all assumptions are reported.
+func (ps *ParallelSolver) FailedAssumptions() []Lit {
+	return ps.failed
 }
 
-// Solve runs the parallel SAT solver
-func (ps *ParallelSolver) Solve() (Result, *parser.Clause) {
-	logger.Info("Starting parallel solver with %d workers\n", ps.NumWorkers)
+// reset prepares the shared state for a new call of Solve and creates the
+// workers' propagators and heuristics on the first call
+func (ps *ParallelSolver) reset() {
+	ps.deques = make([]*WorkDeque, ps.NumWorkers)
+	for id := range ps.deques {
+		ps.deques[id] = NewWorkDeque()
+	}
+	ps.idleCond = sync.NewCond(&ps.idleMu)
+	ps.queued.Store(0)
+	ps.pending.Store(0)
+	ps.sleepers.Store(0)
+	ps.resultChan = make(chan Result, 1)
+	ps.solutionChan = make(chan *parser.Clause, 1)
+	ps.doneChan = make(chan struct{})
+	ps.foundSolution = false
+	ps.bestSolution = nil
+	ps.bestSolutionSize = int(^uint(0) >> 1)
+	ps.lastWorkItem = nil
+	ps.model = nil
+	ps.failed = nil
+	ps.stats = make([]Stats, ps.NumWorkers)
+	ps.workItems = make([]WorkerStats, ps.NumWorkers)
+	ps.decisions.Store(0)
+	ps.conflicts.Store(0)
+	ps.open = [][]Lit{}
+	ps.queueBytes.Store(0)
+	ps.workerBytes = make([]atomic.Int64, ps.NumWorkers)
+	ps.checkpoints = make([]atomic.Int64, ps.NumWorkers)
+	ps.lowMemory.Store(false)
+	ps.stopped.Store(false)
+
+	// Imported clauses propagate more, which would change the sizes of the
+	// solutions optimum mode compares
+	ps.exchange = nil
+	if ps.Options.ShareSize > 0 && !ps.OptimumMode {
+		ps.exchange = newClauseExchange(ps.NumWorkers, ps.Options.ShareSize, ps.Options.ShareLBD)
+	}
 
-	// Create initial work item
-	initialWorkCopy := make([]*parser.Clause, len(ps.Problem.Clauses))
-	for i, clause := range ps.Problem.Clauses {
-		clauseCopy := &parser.Clause{
-			Vars: make([]parser.Variable, len(clause.Vars)),
+	// Each worker keeps one propagator for all its work items, switching
+	// between items only undoes and replays assignments
+	for id := len(ps.engines); id < ps.NumWorkers; id++ {
+		engine := newPropagator(ps.clauses.Clone())
+		heuristic := withPolarity(NewHeuristic(ps.Options.Heuristic, engine.NumVars(), ps.Options.Seed+int64(id)), ps.Options.Polarity)
+		engine.attachHeuristic(heuristic)
+		ps.engines = append(ps.engines, engine)
+		ps.heuristics = append(ps.heuristics, heuristic)
+		ps.imports = append(ps.imports, newImportedClauses(ps.Options.ReduceInterval))
+	}
+
+	if ps.Cubes != nil && ps.Backend == LOOKAHEAD {
+		for id := len(ps.lookaheads); id < ps.NumWorkers; id++ {
+			ps.lookaheads = append(ps.lookaheads, newLookahead(ps.engines[id], lookaheadCandidates))
 		}
-		copy(clauseCopy.Vars, clause.Vars)
-		initialWorkCopy[i] = clauseCopy
 	}
 
-	initialItem := &WorkItem{
-		WorkCopy: initialWorkCopy,
-		Solution: &parser.Clause{},
-		Depth:    0,
+	// Clause learning keeps its learned clauses from one cube to the next
+	if ps.Cubes != nil && ps.Backend == CDCL {
+		for id := len(ps.conquerors); id < ps.NumWorkers; id++ {
+			conquerorOptions := ps.Options
+			conquerorOptions.Seed += int64(id)
+			conqueror := newCDCLSolver(ps.Problem, ps.clauses.Clone(), conquerorOptions)
+			conqueror.quiet = true
+			ps.conquerors = append(ps.conquerors, conqueror)
+		}
 	}
 
-	ps.workQueue.Push(initialItem)
+	for id := range ps.workerBytes {
+		ps.measureWorker(id)
+	}
+}
+
+// Solve runs the parallel SAT solver. The given assumptions are part of every
+// work item, so only assignments in which they are true are searched. It can
+// be called repeatedly. The result is UNKNOWN if the context is done or a
+// budget of the options is used up first, the workers are stopped through
+// the done channel in that case.
+func (ps *ParallelSolver) Solve(ctx context.Context, assumptions ...Lit) (Result, *parser.Clause) {
+	logger.Info("Starting parallel solver with %d workers\n", ps.NumWorkers)
+	ps.reset()
+
+	if ps.Cubes == nil {
+		// Create initial work item
+		initialItem := &WorkItem{
+			Path:  append([]Lit{}, assumptions...),
+			Depth: 0,
+		}
+
+		ps.push(0, initialItem)
+	} else {
+		// Every cube is a work item of its own, spread over the workers
+		if len(ps.Cubes) == 0 {
+			logger.Info("No cubes to solve, the problem is refuted\n")
+			ps.Stats = Stats{Workers: ps.NumWorkers, WorkItems: ps.workItems}
+			ps.failed = append([]Lit{}, assumptions...)
+			return UNSATISFIABLE, nil
+		}
+		logger.Info("Solving %d cubes with %s\n", len(ps.Cubes), ps.Backend)
+		for i, cube := range ps.Cubes {
+			ps.push(i%ps.NumWorkers, &WorkItem{
+				Path:  append(append([]Lit{}, assumptions...), cube...),
+				Depth: len(cube),
+			})
+		}
+	}
+
+	// The CDCL backend only watches its context, it ends with the done channel
+	search, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	// Start worker goroutines
 	for i := 0; i < ps.NumWorkers; i++ {
 		ps.activeWorkers.Add(1)
-		go ps.worker(i)
+		go ps.worker(search, i)
 	}
 
 	// Wait for result
-	result := <-ps.resultChan
+	var result Result
+	select {
+	case result = <-ps.resultChan:
+	case <-ctx.Done():
+		logger.Info("Search cancelled: %v\n", ctx.Err())
+		result = UNKNOWN
+	}
 
 	// Signal all workers to stop
 	close(ps.doneChan)
-	ps.workQueue.Close() // Wake up all waiting workers
+	cancel()
+	ps.wakeAll()
 
 	// Wait for all workers to finish
 	ps.activeWorkers.Wait()
 
+	ps.Stats = Stats{Workers: ps.NumWorkers, WorkItems: ps.workItems}
+	for _, stats := range ps.stats {
+		ps.Stats.Merge(stats)
+	}
+	if result != UNKNOWN {
+		// Cubes given up by the workers after the result was found do not matter
+		ps.open = [][]Lit{}
+	}
+
 	if result == SATISFIABLE {
 		solution := <-ps.solutionChan
 		if ps.OptimumMode {
-			fmt.Printf("\nOptimal solution found with %d variables: %s\n", len(solution.Vars), solution.String())
+			logger.Info("\nOptimal solution found with %d variables: %s\n", len(solution.Vars), solution.String())
 		}
+		model := make([]Lit, len(solution.Vars))
+		for i, cVar := range solution.Vars {
+			model[i] = LitFromVariable(cVar)
+		}
+		ps.model = modelFromTrail(model)
 		return result, solution
 	}
 
-	return result, nil
+	if result == UNSATISFIABLE {
+		ps.failed = append([]Lit{}, assumptions...)
+		return result, nil
+	}
+
+	// Stopped early, the queued work items were not searched either. The best
+	// solution of optimum mode is kept, but it may not be minimal.
+	for _, deque := range ps.deques {
+		for _, item := range deque.Drain() {
+			ps.open = append(ps.open, item.Path)
+		}
+	}
+	bestSol, _ := ps.GetBestSolution()
+	return result, bestSol
 }
 
-// worker is the main worker goroutine that processes work items
-func (ps *ParallelSolver) worker(id int) {
-	defer ps.activeWorkers.Done()
+// OpenCubes returns the parts of the search space a call of Solve stopped
+// with UNKNOWN did not rule out, as the assignments leading to them. Solving
+// with each cube as assumptions continues the search. It is empty after SAT
+// or UNSAT.
+func (ps *ParallelSolver) OpenCubes() [][]Lit {
+	return ps.open
+}
+
+// keepOpen records the parts of a work item a stopped worker did not search
+func (ps *ParallelSolver) keepOpen(s *Solver, item *WorkItem) {
+	s.Stats.Depth = item.Depth + s.CheckpointStack.count
+	cubes := s.OpenCubes()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.open = append(ps.open, cubes...)
+}
 
+// push queues a work item at the bottom of a worker's deque, counts it as
+// pending and adds it to the memory estimate
+func (ps *ParallelSolver) push(workerID int, item *WorkItem) {
+	ps.pending.Add(1)
+	ps.queueBytes.Add(item.size())
+	ps.deques[workerID].Push(item)
+	ps.queued.Add(1)
+
+	// A worker that checked for work before the item was counted is
+	// already waiting and can be woken
+	if ps.sleepers.Load() > 0 {
+		ps.idleMu.Lock()
+		ps.idleCond.Signal()
+		ps.idleMu.Unlock()
+	}
+}
+
+// nextItem returns the next work item of a worker: its own newest one, or
+// the oldest one of another worker. If there is none it waits until work is
+// pushed, and returns nil once the search is over.
+func (ps *ParallelSolver) nextItem(workerID int) *WorkItem {
 	for {
+		if ps.finished() {
+			return nil
+		}
+		if item := ps.deques[workerID].Pop(); item != nil {
+			ps.queued.Add(-1)
+			return item
+		}
+		if item := ps.steal(workerID); item != nil {
+			ps.queued.Add(-1)
+			ps.workItems[workerID].Stolen++
+			return item
+		}
+
+		ps.idleMu.Lock()
+		ps.sleepers.Add(1)
+		for ps.queued.Load() == 0 && !ps.finished() {
+			ps.idleCond.Wait()
+		}
+		ps.sleepers.Add(-1)
+		ps.idleMu.Unlock()
+	}
+}
+
+// steal takes the oldest work item of the first other worker that has one,
+// starting with the next worker so the victims are spread
+func (ps *ParallelSolver) steal(workerID int) *WorkItem {
+	for i := 1; i < ps.NumWorkers; i++ {
+		victim := (workerID + i) % ps.NumWorkers
+		if item := ps.deques[victim].Steal(); item != nil {
+			logger.Detail("Worker %d: Stole work item at depth %d from worker %d\n", workerID, item.Depth, victim)
+			return item
+		}
+	}
+	return nil
+}
+
+// finished reports whether the workers should stop looking for work: the
+// search was stopped, or no work item is pending anymore
+func (ps *ParallelSolver) finished() bool {
+	select {
+	case <-ps.doneChan:
+		return true
+	default:
+	}
+	return ps.pending.Load() == 0 || ps.stopped.Load() || (!ps.OptimumMode && ps.HasFoundSolution())
+}
+
+// wakeAll wakes all workers waiting for work, e.g. to let them see the search ended
+func (ps *ParallelSolver) wakeAll() {
+	ps.idleMu.Lock()
+	defer ps.idleMu.Unlock()
+	ps.idleCond.Broadcast()
+}
+
+// finishItem marks a work item as processed. The worker that finishes the last
+// pending one reports the result of the exhausted search.
+func (ps *ParallelSolver) finishItem(workerID int) {
+	if ps.pending.Add(-1) > 0 {
+		return
+	}
+	defer ps.wakeAll()
+	if ps.stopped.Load() {
+		// Stopped by a budget, the items were given up instead of searched
+		return
+	}
+	logger.Detail("Worker %d: No work item pending\n", workerID)
+
+	if ps.OptimumMode && ps.HasFoundSolution() {
+		// In optimum mode, we found solution(s), report SATISFIABLE
 		select {
-		case <-ps.doneChan:
-			logger.Detail("Worker %d: Received done signal\n", id)
-			return
+		case ps.resultChan <- SATISFIABLE:
+			bestSol, bestSize := ps.GetBestSolution()
+			logger.Info("Worker %d: Search exhausted. Best solution has %d variables\n", workerID, bestSize)
+			select {
+			case ps.solutionChan <- bestSol:
+			default:
+			}
+		default:
+			// Result already sent
+		}
+	} else if !ps.HasFoundSolution() {
+		// No solution found at all - problem is UNSAT
+		select {
+		case ps.resultChan <- UNSATISFIABLE:
+			logger.Info("Worker %d: Reporting UNSATISFIABLE\n", workerID)
 		default:
+			// Result already sent
 		}
+	}
+}
 
-		// Check if solution was found (only in non-optimum mode)
-		if !ps.OptimumMode && ps.HasFoundSolution() {
-			logger.Detail("Worker %d: Solution found by another worker, stopping\n", id)
+// worker is the main worker goroutine that processes work items
+func (ps *ParallelSolver) worker(ctx context.Context, id int) {
+	defer ps.activeWorkers.Done()
+
+	engine, heuristic := ps.engines[id], ps.heuristics[id]
+
+	for {
+		if ps.budgetExhausted(id) {
 			return
 		}
 
-		// Try to get work (blocks if queue is empty)
-		item := ps.workQueue.Pop()
-
+		// Take work from the own deque or steal it, blocks until there is some
+		item := ps.nextItem(id)
 		if item == nil {
-			// Queue is closed or we woke up to check termination
-			// Check if we should send final result
-			if ps.workQueue.Len() == 0 && ps.GetBusyWorkers() == 0 {
-				logger.Detail("Worker %d: No work available and no busy workers\n", id)
-
-				if ps.OptimumMode && ps.HasFoundSolution() {
-					// In optimum mode, we found solution(s), report SATISFIABLE
-					select {
-					case ps.resultChan <- SATISFIABLE:
-						bestSol, bestSize := ps.GetBestSolution()
-						logger.Info("Worker %d: Search exhausted. Best solution has %d variables\n", id, bestSize)
-						select {
-						case ps.solutionChan <- bestSol:
-						default:
-						}
-					default:
-						// Result already sent
-					}
-				} else if !ps.HasFoundSolution() {
-					// No solution found at all - problem is UNSAT
-					select {
-					case ps.resultChan <- UNSATISFIABLE:
-						logger.Info("Worker %d: Reporting UNSATISFIABLE\n", id)
-					default:
-						// Result already sent
-					}
-				}
-			}
+			logger.Detail("Worker %d: Search over, stopping\n", id)
 			return
 		}
-
-		// Mark this worker as busy
-		ps.IncrementBusyWorkers()
+		ps.queueBytes.Add(-item.size())
+		ps.workItems[id].Processed++
 
 		// Process this work item
 		logger.Detail("Worker %d: Processing work item at depth %d\n", id, item.Depth)
-		ps.processWorkItem(item, id)
-
-		// Mark this worker as idle
-		ps.DecrementBusyWorkers()
+		if ps.Cubes != nil && ps.Backend == CDCL {
+			ps.conquerCube(ctx, item, id)
+		} else {
+			ps.processWorkItem(item, engine, heuristic, id)
+		}
+		ps.finishItem(id)
 	}
 }
 
 // processWorkItem solves a single work item
-func (ps *ParallelSolver) processWorkItem(item *WorkItem, workerID int) {
+func (ps *ParallelSolver) processWorkItem(item *WorkItem, engine *propagator, heuristic BranchingHeuristic, workerID int) {
 	// Store this as the last examined work item
 	ps.SetLastWorkItem(item)
 
-	// Create a solver for this work item
+	// Create a solver for this work item on the worker's propagator
 	s := &Solver{
 		Problem:         ps.Problem,
-		WorkCopy:        item.WorkCopy,
-		Solution:        item.Solution,
 		Result:          UNKNOWN,
 		CheckpointStack: &CheckpointStack{},
+		engine:          engine,
+		heuristic:       heuristic,
+	}
+	if ps.Cubes != nil && ps.Backend == LOOKAHEAD {
+		s.look = ps.lookaheads[workerID]
+	}
+
+	// The depth counts the splits that led to the work item as well
+	propagations := engine.propagations
+	defer func() {
+		s.Stats.Propagations = engine.propagations - propagations
+		s.Stats.MaxDepth += item.Depth
+		ps.stats[workerID].Merge(s.Stats)
+		ps.checkpoints[workerID].Store(0)
+	}()
+	engine.cancelUntil(0)
+	if ps.exchange != nil {
+		// Work items start on level 0, where unit clauses of the other workers
+		// can be added and the imported clauses can be reduced
+		ps.importClauses(workerID)
+		ps.reduceImported(workerID)
+		ps.measureWorker(workerID)
+		s.learn = func(lits []Lit) {
+			if ps.exchange.offer(workerID, lits, engine) {
+				ps.workItems[workerID].Shared++
+			}
+		}
+	}
+
+	// Every assignment of the work item gets a decision level of its own, so
+	// the levels a learned clause spans say how many decisions it ties
+	// together. Backtracking never goes below them.
+	for _, lit := range item.Path {
+		engine.reserveVars(lit.Var())
+		switch engine.Value(lit) {
+		case 0:
+			engine.newDecisionLevel()
+			engine.assign(lit, -1)
+			if engine.propagate() >= 0 {
+				logger.Detail("Worker %d: Work item refuted while replaying it\n", workerID)
+				s.Stats.Conflicts++
+				ps.conflicts.Add(1)
+				if s.learn != nil {
+					s.learn(s.lemma(engine.trail))
+				}
+				return
+			}
+		case -1:
+			logger.Detail("Worker %d: Work item contradicts itself, skipping\n", workerID)
+			if s.learn != nil {
+				s.learn(s.lemma(engine.trail, lit.Not()))
+			}
+			return
+		}
+	}
+	if engine.decisionLevel() == 0 {
+		engine.newDecisionLevel()
 	}
+	imported := -1 // Imported clause falsified by the current assignment
 
 	// Run the solving loop
 	for {
 		// Check if we should stop
 		select {
 		case <-ps.doneChan:
+			ps.keepOpen(s, item)
 			return
 		default:
 		}
@@ -332,15 +673,55 @@ func (ps *ParallelSolver) processWorkItem(item *WorkItem, workerID int) {
 			return
 		}
 
+		ps.checkpoints[workerID].Store(int64(s.CheckpointStack.count))
+		if ps.budgetExhausted(workerID) {
+			ps.keepOpen(s, item)
+			return
+		}
+
+		start := time.Now()
+		conflict := s.engine.propagate()
+		addTime(&s.Stats.Time.Propagation, start)
+		if conflict < 0 {
+			conflict = imported
+		}
+		imported = -1
+		if conflict >= 0 {
+			logger.Detail("Worker %d: Found contradiction, backtracking...\n", workerID)
+			s.Stats.Conflicts++
+			ps.conflicts.Add(1)
+			heuristic.Bump(engine.db.Lits(conflict))
+			if learned := ps.imports[workerID].learned; learned.get(conflict) != nil {
+				learned.bump(conflict, engine.levelCount(engine.db.Lits(conflict)))
+			}
+			ps.imports[workerID].learned.decay()
+			if s.backtrack() {
+				logger.Detail("Worker %d: Backtracking to previous checkpoint\n", workerID)
+				if ps.exchange != nil && s.Stats.Backtracks%importInterval == 0 {
+					imported = ps.importClauses(workerID)
+					ps.measureWorker(workerID)
+				}
+				continue
+			}
+			logger.Detail("Worker %d: No checkpoints left, branch exhausted\n", workerID)
+			if s.learn != nil && !s.partial {
+				// The assignments of the work item are refuted as a whole
+				s.learn(s.lemma(engine.trail))
+			}
+			return
+		}
+
 		if s.isSolved() {
+			solution := s.currentSolution()
 			if ps.OptimumMode {
 				// In optimum mode, check if this is a better solution
-				if ps.UpdateBestSolution(s.Solution) {
+				if ps.UpdateBestSolution(solution) {
 					bestSol, bestSize := ps.GetBestSolution()
 					logger.Info("Worker %d: Found better solution (size %d): %s\n", workerID, bestSize, bestSol.String())
-					fmt.Printf("Found solution with %d variables: %s\n", bestSize, bestSol.String())
+					logger.Info("Found solution with %d variables: %s\n", bestSize, bestSol.String())
 				}
 				// Don't return - try to backtrack and explore other branches
+				s.leaveOpen()
 				if s.backtrack() {
 					logger.Detail("Worker %d: Backtracking after solution to explore more branches\n", workerID)
 					continue
@@ -350,56 +731,45 @@ func (ps *ParallelSolver) processWorkItem(item *WorkItem, workerID int) {
 				return
 			} else {
 				// In normal mode, stop at first solution
-				logger.Info("Worker %d: Found solution: %s\n", workerID, s.Solution.String())
+				logger.Info("Worker %d: Found solution: %s\n", workerID, solution.String())
 				ps.SetFoundSolution()
 				select {
 				case ps.resultChan <- SATISFIABLE:
 				default:
 				}
 				select {
-				case ps.solutionChan <- s.Solution:
+				case ps.solutionChan <- solution:
 				default:
 				}
-				ps.workQueue.Close() // Wake up all waiting workers
+				ps.wakeAll()
 				return
 			}
 		}
 
-		if s.isUnsolvable() {
-			logger.Detail("Worker %d: Branch unsolvable\n", workerID)
-			return
-		}
-
-		if s.hasContradiction() {
-			logger.Detail("Worker %d: Found contradiction, backtracking...\n", workerID)
-			if s.backtrack() {
-				logger.Detail("Worker %d: Backtracking to previous checkpoint\n", workerID)
-				continue
-			}
-			logger.Detail("Worker %d: No checkpoints left, branch exhausted\n", workerID)
-			return
-		}
-
-		if s.unitPropagation() {
-			logger.Detail("Worker %d: Unit propagation, remaining: %d\n", workerID, len(s.WorkCopy))
-			continue
-		}
-
 		if s.pureLiteral() {
-			logger.Detail("Worker %d: Pure literal, remaining: %d\n", workerID, len(s.WorkCopy))
+			logger.Detail("Worker %d: Pure literal\n", workerID)
 			continue
 		}
 
-		// Handle split - this is where parallelization happens
-		if ps.shouldParallelize(item.Depth) {
-			// Parallelize this split
+		// Handle split - this is where parallelization happens, cubes are
+		// already split by the lookahead and searched sequentially
+		if ps.Cubes == nil && ps.shouldParallelize(s, item.Depth, workerID) {
+			// Parallelize this split, both branches are handed to the queue so
+			// this worker continues with its own open checkpoints, if any
 			if ps.parallelSplit(s, item.Depth, workerID) {
 				logger.Detail("Worker %d: Created parallel split at depth %d\n", workerID, item.Depth)
-				continue
+				s.leaveOpen()
+				if s.backtrack() {
+					logger.Detail("Worker %d: Backtracking\n", workerID)
+					continue
+				}
+				return
 			}
 		} else {
-			// Use sequential split with checkpoints
+			// Use sequential split with checkpoints, a lookahead may only find failed literals
+			decisions := s.Stats.Decisions
 			if s.split() {
+				ps.decisions.Add(int64(s.Stats.Decisions - decisions))
 				logger.Detail("Worker %d: Sequential split (depth %d)\n", workerID, item.Depth)
 				continue
 			}
@@ -415,107 +785,223 @@ func (ps *ParallelSolver) processWorkItem(item *WorkItem, workerID int) {
 	}
 }
 
-// shouldParallelize determines if we should create parallel work at this depth
-func (ps *ParallelSolver) shouldParallelize(currentDepth int) bool {
-	// Check queue size first - don't create more work if queue is full
-	if ps.workQueue.Len() >= ps.maxQueueSize {
-		return false
+// importClauses publishes the clauses a worker learned and adds the ones the
+// others published as learned clauses under the current assignment. It
+// returns one of them falsified by the assignment, or -1 if there is none.
+// Unit clauses are put off until the worker is back on level 0.
+func (ps *ParallelSolver) importClauses(workerID int) int {
+	engine, imports := ps.engines[workerID], ps.imports[workerID]
+	clauses := ps.exchange.sync(workerID)
+	if engine.decisionLevel() == 0 {
+		clauses = append(imports.deferred, clauses...)
+		imports.deferred = nil
 	}
 
-	// Check depth limit
-	if ps.ParallelDepth == 0 {
-		return true // Unlimited depth (but still limited by queue size)
+	falsified := -1
+	for _, clause := range clauses {
+		if len(clause.lits) < 2 && engine.decisionLevel() > 0 {
+			imports.deferred = append(imports.deferred, clause)
+			continue
+		}
+		clauseID, conflict := engine.addImported(clause.lits)
+		imports.learned.add(clauseID, clause.lbd)
+		if conflict && falsified < 0 {
+			falsified = clauseID
+		}
+		ps.workItems[workerID].Imported++
 	}
-	return currentDepth < ps.ParallelDepth
+	return falsified
 }
 
-// parallelSplit creates two work items for the split variable
-func (ps *ParallelSolver) parallelSplit(s *Solver, currentDepth int, workerID int) bool {
-	clauses := s.WorkCopy
+// reduceImported deletes imported clauses of little value once the worker
+// took enough of them, with the tiers of the CDCL solver. It is called on
+// level 0, where only the reasons of facts are locked.
+func (ps *ParallelSolver) reduceImported(workerID int) {
+	engine, imports := ps.engines[workerID], ps.imports[workerID]
+	if imports.nextReduce == 0 || len(imports.learned.clauses) < imports.nextReduce {
+		return
+	}
 
-	// Find the most used variable (same logic as sequential split)
-	variableUsageMap := make(map[int]int)
-	for _, clause := range clauses {
-		for _, cVar := range clause.Vars {
-			if cVar.Impossible {
-				continue
-			}
-			variableUsageMap[cVar.ID] = variableUsageMap[cVar.ID] + 1
+	deleted := imports.learned.reduce(engine.db, engine.isReason)
+	remap := engine.db.Compact()
+	engine.relocate(remap)
+	imports.learned.relocate(remap, engine.db.Len())
+	imports.nextReduce = len(imports.learned.clauses) + ps.Options.ReduceInterval
+	if workerID < len(ps.lookaheads) {
+		ps.lookaheads[workerID].reindex()
+	}
+	logger.Detail("Worker %d: Reduced the imported clauses by %d, %d are left\n", workerID, deleted, len(imports.learned.clauses))
+}
+
+// conquerCube solves the cube of a work item with the worker's CDCL solver
+func (ps *ParallelSolver) conquerCube(ctx context.Context, item *WorkItem, workerID int) {
+	ps.SetLastWorkItem(item)
+	s := ps.conquerors[workerID]
+
+	// The budgets are shared by the workers, a cube gets what is left of them
+	s.options.MaxDecisions = remainingBudget(ps.Options.MaxDecisions, ps.decisions.Load())
+	s.options.MaxConflicts = remainingBudget(ps.Options.MaxConflicts, ps.conflicts.Load())
+	s.Solve(ctx, item.Path...)
+	ps.measureWorker(workerID)
+	ps.decisions.Add(int64(s.Stats.Decisions))
+	ps.conflicts.Add(int64(s.Stats.Conflicts))
+	ps.stats[workerID].Merge(s.Stats)
+
+	switch s.Result {
+	case SATISFIABLE:
+		logger.Info("Worker %d: Found solution: %s\n", workerID, s.Solution.String())
+		ps.SetFoundSolution()
+		select {
+		case ps.resultChan <- SATISFIABLE:
+		default:
+		}
+		select {
+		case ps.solutionChan <- s.Solution:
+		default:
 		}
+		ps.wakeAll()
+	case UNKNOWN:
+		// Stopped by the context or a budget, the cube has to be searched again.
+		// A used up budget is reported before the item counts as finished.
+		ps.mu.Lock()
+		ps.open = append(ps.open, item.Path)
+		ps.mu.Unlock()
+		ps.budgetExhausted(workerID)
+	default:
+		logger.Detail("Worker %d: Cube refuted\n", workerID)
+	}
+}
+
+// remainingBudget returns what is left of a budget shared by the workers, 0
+// if it is unlimited. At least 1 is left, so a used up budget is not mistaken
+// for an unlimited one.
+func remainingBudget(budget int, used int64) int {
+	if budget <= 0 {
+		return 0
 	}
+	return max(budget-int(used), 1)
+}
 
-	maxVarID := 0
-	maxCount := 0
-	for variableID, count := range variableUsageMap {
-		if count > maxCount {
-			maxCount = count
-			maxVarID = variableID
+// budgetExhausted reports whether the workers together used up a budget of
+// the options or exceeded the memory limit. The first worker to notice
+// reports UNKNOWN; the result is sent before any branch is given up,
+// otherwise the last worker to finish would report UNSATISFIABLE. The memory
+// limit only stops the search: work items are never spilled to disk, and
+// maxQueueSize stays at four per worker whatever the limit.
+func (ps *ParallelSolver) budgetExhausted(workerID int) bool {
+	decisions, conflicts := ps.decisions.Load(), ps.conflicts.Load()
+	memory := ps.memoryUsed()
+	outOfMemory := ps.Options.MemLimit > 0 && memory > ps.Options.MemLimit
+	if !outOfMemory && !ps.Options.exhausted(int(decisions), int(conflicts)) {
+		return false
+	}
+	if !ps.stopped.CompareAndSwap(false, true) {
+		// Another worker noticed first
+		return true
+	}
+	select {
+	case ps.resultChan <- UNKNOWN:
+		if outOfMemory {
+			logger.Info("Worker %d: Search stopped, work items, checkpoints and worker clauses use about %d bytes, more than the limit of %d\n", workerID, memory, ps.Options.MemLimit)
+		} else {
+			logger.Info("Worker %d: Search stopped after %d decisions and %d conflicts\n", workerID, decisions, conflicts)
 		}
+		ps.wakeAll()
+	default:
+		// Result already sent
 	}
+	return true
+}
+
+// memoryUsed estimates the bytes held by the queued work items, the open
+// checkpoints and the clauses and propagation state of every worker
+func (ps *ParallelSolver) memoryUsed() int64 {
+	memory := ps.queueBytes.Load()
+	for id := range ps.checkpoints {
+		memory += ps.checkpoints[id].Load()*checkpointBytes + ps.workerBytes[id].Load()
+	}
+	return memory
+}
+
+// measureWorker updates the memory estimate of a worker's clauses, called by
+// the worker itself whenever they may have grown
+func (ps *ParallelSolver) measureWorker(workerID int) {
+	memory := ps.engines[workerID].memory()
+	if workerID < len(ps.lookaheads) {
+		memory += ps.lookaheads[workerID].memory()
+	}
+	if workerID < len(ps.conquerors) {
+		memory += ps.conquerors[workerID].engine.memory()
+	}
+	ps.workerBytes[workerID].Store(memory)
+}
 
-	if maxVarID == 0 {
+// nearMemoryLimit reports whether splitting off two work items with the
+// given number of assignments would bring the memory estimate close to the
+// limit. The workers split sequentially then, which needs a checkpoint
+// instead of a copy of the assignments, until the queue shrinks again. This
+// is all the limit changes about the search, maxQueueSize is not lowered.
+func (ps *ParallelSolver) nearMemoryLimit(workerID int, assignments int) bool {
+	if ps.Options.MemLimit <= 0 {
 		return false
 	}
+	split := 2 * (workItemBytes + int64(assignments+1)*litBytes)
+	if ps.memoryUsed()+split <= ps.Options.MemLimit*3/4 {
+		return false
+	}
+	if ps.lowMemory.CompareAndSwap(false, true) {
+		logger.Info("Worker %d: Work items, checkpoints and worker clauses use about %d of %d bytes, splitting sequentially\n", workerID, ps.memoryUsed(), ps.Options.MemLimit)
+	}
+	return true
+}
 
-	// Find the variable
-	var pickedVariable *parser.Variable
-	for _, clause := range clauses {
-		if pickedVariable != nil {
-			break
-		}
-		for _, cVar := range clause.Vars {
-			if cVar.ID == maxVarID {
-				pickedVariable = &cVar
-				break
-			}
-		}
+// shouldParallelize determines if we should create parallel work at this depth
+func (ps *ParallelSolver) shouldParallelize(s *Solver, currentDepth int, workerID int) bool {
+	// Check queue size first - don't create more work if queue is full
+	if ps.queued.Load() >= int64(ps.maxQueueSize) {
+		return false
 	}
 
-	if pickedVariable == nil {
+	if ps.nearMemoryLimit(workerID, len(s.engine.trail)) {
 		return false
 	}
 
-	logger.Detail("Worker %d: Split on variable %s\n", workerID, pickedVariable.String())
+	// Check depth limit
+	if ps.ParallelDepth == 0 {
+		return true // Unlimited depth (but still limited by queue size)
+	}
+	return currentDepth < ps.ParallelDepth
+}
 
-	// Create two branches - one with the variable as-is, one with negated
-	for _, negated := range []bool{pickedVariable.Negated, !pickedVariable.Negated} {
-		splitVar := &parser.Variable{
-			ID:         pickedVariable.ID,
-			Negated:    negated,
-			Impossible: false,
-		}
-
-		// Deep copy current state
-		newWorkCopy := make([]*parser.Clause, len(s.WorkCopy))
-		for i, clause := range s.WorkCopy {
-			clauseCopy := &parser.Clause{
-				Vars: make([]parser.Variable, len(clause.Vars)),
-			}
-			copy(clauseCopy.Vars, clause.Vars)
-			newWorkCopy[i] = clauseCopy
-		}
+// parallelSplit creates two work items for the split variable
+func (ps *ParallelSolver) parallelSplit(s *Solver, currentDepth int, workerID int) bool {
+	defer addTime(&s.Stats.Time.Decisions, time.Now())
+	engine := s.engine
 
-		newSolution := &parser.Clause{
-			Vars: make([]parser.Variable, len(s.Solution.Vars)),
-		}
-		copy(newSolution.Vars, s.Solution.Vars)
-		newSolution.Vars = append(newSolution.Vars, *splitVar)
+	// Pick the split variable with the same heuristic as the sequential split
+	pickedLit, ok := s.heuristic.PickBranch(engine)
+	if !ok {
+		return false
+	}
 
-		// Reduce the working set with this variable
-		tmpSolver := &Solver{
-			WorkCopy: newWorkCopy,
-			Solution: newSolution,
-		}
-		tmpSolver.reduceWorkingSet(splitVar)
+	logger.Detail("Worker %d: Split on variable %s\n", workerID, pickedLit)
+	s.Stats.Decisions++
+	s.Stats.MaxDepth = max(s.Stats.MaxDepth, s.CheckpointStack.count+1)
+	ps.decisions.Add(1)
+
+	// Create two branches - one with the variable as-is, one with negated
+	for _, splitLit := range []Lit{pickedLit, pickedLit.Not()} {
+		// Create work item for this branch, it only carries the decisions
+		// leading to it, the worker taking it propagates the rest again
+		path := append(s.decisions(engine.trail), splitLit)
 
-		// Create work item for this branch
 		workItem := &WorkItem{
-			WorkCopy: tmpSolver.WorkCopy,
-			Solution: tmpSolver.Solution,
-			Depth:    currentDepth + 1,
+			Path:  path,
+			Depth: currentDepth + 1,
 		}
 
-		ps.workQueue.Push(workItem)
+		ps.push(workerID, workItem)
+		ps.workItems[workerID].Created++
 	}
 
 	// This worker is done with this branch - work items pushed to queue
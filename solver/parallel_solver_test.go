@@ -0,0 +1,173 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWorkDequeOrder(t *testing.T) {
+	wd := NewWorkDeque()
+	items := []*WorkItem{{Depth: 1}, {Depth: 2}, {Depth: 3}, {Depth: 4}}
+	for _, item := range items {
+		wd.Push(item)
+	}
+
+	// The owner continues with the newest item, thieves take the oldest
+	if item := wd.Pop(); item != items[3] {
+		t.Errorf("Pop returned depth %d, want 4", item.Depth)
+	}
+	if item := wd.Steal(); item != items[0] {
+		t.Errorf("Steal returned depth %d, want 1", item.Depth)
+	}
+	if wd.Len() != 2 {
+		t.Errorf("Len %d, want 2", wd.Len())
+	}
+
+	drained := wd.Drain()
+	if len(drained) != 2 || drained[0] != items[1] || drained[1] != items[2] {
+		t.Errorf("Drain returned %d items, want depths 2 and 3", len(drained))
+	}
+	if wd.Len() != 0 || wd.Pop() != nil || wd.Steal() != nil {
+		t.Error("items left after Drain")
+	}
+}
+
+func TestWorkDequeConcurrentSteals(t *testing.T) {
+	const numItems = 10000
+	wd := NewWorkDeque()
+	taken := make([]int, numItems)
+	var mu sync.Mutex
+	take := func(item *WorkItem) {
+		mu.Lock()
+		taken[item.Depth]++
+		mu.Unlock()
+	}
+
+	var thieves sync.WaitGroup
+	done := make(chan struct{})
+	for range 4 {
+		thieves.Add(1)
+		go func() {
+			defer thieves.Done()
+			for {
+				if item := wd.Steal(); item != nil {
+					take(item)
+					continue
+				}
+				select {
+				case <-done:
+					return
+				default:
+				}
+			}
+		}()
+	}
+
+	// The owner pushes every item and pops every third one itself
+	for i := range numItems {
+		wd.Push(&WorkItem{Depth: i})
+		if i%3 == 0 {
+			if item := wd.Pop(); item != nil {
+				take(item)
+			}
+		}
+	}
+	for item := wd.Pop(); item != nil; item = wd.Pop() {
+		take(item)
+	}
+	close(done)
+	thieves.Wait()
+
+	for i, count := range taken {
+		if count != 1 {
+			t.Fatalf("item %d taken %d times", i, count)
+		}
+	}
+}
+
+func TestParallelSolvesExamples(t *testing.T) {
+	tests := []struct {
+		folder string
+		want   Result
+	}{
+		{"uf20-91", SATISFIABLE},
+		{"uf50-218", SATISFIABLE},
+		{"uuf50-218", UNSATISFIABLE},
+	}
+	for _, tt := range tests {
+		for _, file := range exampleFiles(t, tt.folder) {
+			task := loadTask(t, file)
+			for _, run := range []struct {
+				workers int
+				sharing bool
+			}{{1, true}, {2, true}, {4, true}, {4, false}} {
+				options := DefaultOptions()
+				name := fmt.Sprintf("%s with %d workers", filepath.Base(file), run.workers)
+				if !run.sharing {
+					options.ShareSize = 0
+					name += " without sharing"
+				}
+				ps := NewParallelSolver(task, run.workers, 0, false, options)
+				result, solution := ps.Solve(context.Background())
+				if result != tt.want {
+					t.Fatalf("%s: result %s, want %s", name, result, tt.want)
+				}
+				if result != SATISFIABLE {
+					continue
+				}
+				if err := Verify(task, solution); err != nil {
+					t.Errorf("%s: solution rejected: %v", name, err)
+				}
+				if err := Verify(task, ClauseFromLits(ps.Model())); err != nil {
+					t.Errorf("%s: model rejected: %v", name, err)
+				}
+			}
+		}
+	}
+}
+
+func TestParallelOptimumMode(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uf20-91")[0])
+	ps := NewParallelSolver(task, 4, 3, true, DefaultOptions())
+	result, solution := ps.Solve(context.Background())
+	if result != SATISFIABLE {
+		t.Fatalf("result %s, want %s", result, SATISFIABLE)
+	}
+	if err := Verify(task, solution); err != nil {
+		t.Errorf("solution rejected: %v", err)
+	}
+}
+
+func TestParallelMemoryLimit(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+
+	options := DefaultOptions()
+	options.MemLimit = 1 << 30
+	ps := NewParallelSolver(task, 4, 0, false, options)
+	if result, _ := ps.Solve(context.Background()); result != UNSATISFIABLE {
+		t.Fatalf("result %s within a generous limit, want %s", result, UNSATISFIABLE)
+	}
+	used := ps.memoryUsed()
+	if used <= 0 {
+		t.Fatalf("memory estimate %d after solving", used)
+	}
+
+	// The clauses the workers hold count as well, so a limit below them
+	// stops the search right away
+	options.MemLimit = used / 2
+	ps = NewParallelSolver(task, 4, 0, false, options)
+	if result, _ := ps.Solve(context.Background()); result != UNKNOWN {
+		t.Errorf("result %s with a limit of %d bytes, want %s", result, options.MemLimit, UNKNOWN)
+	}
+
+	// The limit only stops the search, the queue keeps its size
+	if ps.maxQueueSize != 4*4 {
+		t.Errorf("queue limited to %d work items, want 16 with any memory limit", ps.maxQueueSize)
+	}
+	if !ps.nearMemoryLimit(0, 1) {
+		t.Error("split not made sequential above the memory limit")
+	}
+}
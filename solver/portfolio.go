@@ -0,0 +1,224 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/CptPie/DLPP-solver/logger"
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// PortfolioConfig is the configuration one worker of the portfolio solver
+// searches with
+type PortfolioConfig struct {
+	Algorithm Algorithm
+	Options   Options
+}
+
+func (c PortfolioConfig) String() string {
+	if c.Algorithm == LOOKAHEAD {
+		// The lookahead picks the split variables instead of the heuristic
+		return c.Algorithm.String()
+	}
+	description := fmt.Sprintf("%s, %s", c.Algorithm, c.Options.Heuristic)
+	if c.Options.Heuristic == RANDOM {
+		description += fmt.Sprintf(" (seed %d)", c.Options.Seed)
+	}
+	if c.Algorithm == CDCL && c.Options.Restart != NoRestarts {
+		description += fmt.Sprintf(", %s restarts", c.Options.Restart)
+	}
+	if c.Options.Polarity != HeuristicPolarity {
+		description += fmt.Sprintf(", %s polarity", c.Options.Polarity)
+	}
+	return description
+}
+
+// portfolio lists the configurations the workers after the first one take
+// turns with, alternating between clause learning and DPLL
+var portfolio = []PortfolioConfig{
+	{CDCL, Options{Heuristic: VSIDS, Restart: LubyRestarts}},
+	{DPLL, Options{Heuristic: JW2}},
+	{CDCL, Options{Heuristic: VSIDS, Restart: GlucoseRestarts, Polarity: NegativePolarity}},
+	{DPLL, Options{Heuristic: MOMS, Polarity: NegativePolarity}},
+	{CDCL, Options{Heuristic: VSIDS, Restart: GeometricRestarts, Polarity: PositivePolarity}},
+	{LOOKAHEAD, Options{}},
+	{CDCL, Options{Heuristic: RANDOM, Restart: LubyRestarts}},
+	{DPLL, Options{Heuristic: RANDOM}},
+}
+
+// PortfolioConfigs returns the configurations of the given number of workers.
+// The first worker searches with the given algorithm and options, the others
+// take turns with the portfolio and only keep the intervals and budgets of
+// the options. Once the portfolio is used up the workers repeat it with
+// random decisions, each with a seed of its own.
+func PortfolioConfigs(numWorkers int, algorithm Algorithm, options Options) []PortfolioConfig {
+	configs := []PortfolioConfig{{algorithm, options}}
+	for id := 1; id < numWorkers; id++ {
+		config := portfolio[(id-1)%len(portfolio)]
+		if id > len(portfolio) {
+			config.Options.Heuristic = RANDOM
+		}
+
+		diversified := options
+		diversified.Heuristic = config.Options.Heuristic
+		diversified.Restart = config.Options.Restart
+		diversified.Polarity = config.Options.Polarity
+		diversified.Seed = options.Seed + int64(id)
+		configs = append(configs, PortfolioConfig{config.Algorithm, diversified})
+	}
+	return configs
+}
+
+// PortfolioSolver runs complete sequential searches of the whole task side
+// by side, every worker with a different configuration. The first worker to
+// finish decides the result, the others are cancelled through the done
+// channel.
+type PortfolioSolver struct {
+	Problem *parser.Task
+	Configs []PortfolioConfig // Per worker: the configuration it searches with
+	Winner  int               // Worker that decided the last call of Solve, -1 if none did
+	Stats   Stats             // Counters of the last call of Solve, summed over the workers
+
+	resultChan    chan portfolioResult
+	doneChan      chan struct{}
+	activeWorkers sync.WaitGroup
+	added         [][]Lit // Clauses added between calls, given to the solvers of every call
+	stats         []Stats // Per worker: counters of the current call of Solve
+	model         []Lit   // Assignment of the last satisfiable call, ordered by variable
+	failed        []Lit   // Assumptions responsible for the last unsatisfiable call
+}
+
+// portfolioResult is what the worker finishing first reports
+type portfolioResult struct {
+	workerID int
+	result   Result
+	solution *parser.Clause
+	model    []Lit
+	failed   []Lit
+}
+
+func NewPortfolioSolver(task *parser.Task, configs []PortfolioConfig) *PortfolioSolver {
+	return &PortfolioSolver{
+		Problem: task,
+		Configs: configs,
+		Winner:  -1,
+	}
+}
+
+// AddClause adds a clause to the problem between calls of Solve
+func (ps *PortfolioSolver) AddClause(lits ...Lit) {
+	ps.added = append(ps.added, append([]Lit{}, lits...))
+}
+
+// Model returns the assignment found by the last call of Solve, ordered by
+// variable, or nil if it was not satisfiable
+func (ps *PortfolioSolver) Model() []Lit {
+	return ps.model
+}
+
+// FailedAssumptions returns the assumptions which made the last call of Solve
+// unsatisfiable, as reported by the worker that decided it
+func (ps *PortfolioSolver) FailedAssumptions() []Lit {
+	return ps.failed
+}
+
+// Solve runs one search per configuration under the given assumptions and
+// returns the result of the first one to finish. Every call starts fresh
+// solvers. The result is UNKNOWN if the context is done, or every worker
+// used up a budget of its options, first.
+func (ps *PortfolioSolver) Solve(ctx context.Context, assumptions ...Lit) (Result, *parser.Clause) {
+	logger.Info("Starting portfolio solver with %d workers\n", len(ps.Configs))
+	ps.resultChan = make(chan portfolioResult, 1)
+	ps.doneChan = make(chan struct{})
+	ps.stats = make([]Stats, len(ps.Configs))
+	ps.Winner = -1
+	ps.model = nil
+	ps.failed = nil
+
+	// The searches only watch their context, it ends with the done channel
+	search, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ps.doneChan
+		cancel()
+	}()
+
+	for id, config := range ps.Configs {
+		logger.Info("Worker %d: %s\n", id, config)
+		ps.activeWorkers.Add(1)
+		go ps.worker(search, id, config, assumptions)
+	}
+
+	// Workers that give up do not report, so the result is UNKNOWN once all of them are done
+	finished := make(chan struct{})
+	go func() {
+		ps.activeWorkers.Wait()
+		close(finished)
+	}()
+
+	result := UNKNOWN
+	var solution *parser.Clause
+	select {
+	case winner := <-ps.resultChan:
+		logger.Info("Worker %d (%s) finished first: %s\n", winner.workerID, ps.Configs[winner.workerID], winner.result)
+		result, solution = winner.result, winner.solution
+		ps.Winner = winner.workerID
+		ps.model, ps.failed = winner.model, winner.failed
+	case <-finished:
+		logger.Info("All workers stopped before a result was found\n")
+	case <-ctx.Done():
+		logger.Info("Search cancelled: %v\n", ctx.Err())
+	}
+
+	// Signal all workers to stop and wait for them
+	close(ps.doneChan)
+	<-finished
+
+	ps.Stats = Stats{}
+	for _, stats := range ps.stats {
+		ps.Stats.Merge(stats)
+	}
+	ps.Stats.Workers = len(ps.Configs)
+	return result, solution
+}
+
+// worker runs a complete sequential search with its configuration and
+// reports its result unless another worker was faster
+func (ps *PortfolioSolver) worker(ctx context.Context, id int, config PortfolioConfig, assumptions []Lit) {
+	defer ps.activeWorkers.Done()
+
+	var report portfolioResult
+	if config.Algorithm == CDCL {
+		s := NewCDCLSolver(ps.Problem, config.Options)
+		s.quiet = true
+		for _, lits := range ps.added {
+			s.AddClause(lits...)
+		}
+		s.Solve(ctx, assumptions...)
+		ps.stats[id] = s.Stats
+		report = portfolioResult{id, s.Result, s.Solution, s.Model(), s.FailedAssumptions()}
+	} else {
+		newSolver := NewSolver
+		if config.Algorithm == LOOKAHEAD {
+			newSolver = NewLookaheadSolver
+		}
+		s := newSolver(ps.Problem, config.Options)
+		for _, lits := range ps.added {
+			s.AddClause(lits...)
+		}
+		s.Solve(ctx, assumptions...)
+		ps.stats[id] = s.Stats
+		report = portfolioResult{id, s.Result, s.Solution, s.Model(), s.FailedAssumptions()}
+	}
+
+	if report.result == UNKNOWN {
+		logger.Step("Worker %d: stopped without a result\n", id)
+		return
+	}
+	select {
+	case ps.resultChan <- report:
+	case <-ps.doneChan:
+		// Another worker was faster
+	}
+}
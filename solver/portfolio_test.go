@@ -0,0 +1,133 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPortfolioConfigs(t *testing.T) {
+	options := DefaultOptions()
+	options.Heuristic = MOMS
+	options.RestartInterval = 7
+	options.ReduceInterval = 30
+	options.MaxConflicts = 500
+	configs := PortfolioConfigs(2*len(portfolio)+1, DPLL, options)
+
+	if configs[0].Algorithm != DPLL || configs[0].Options != options {
+		t.Errorf("worker 0 searches with %s, want the given configuration", configs[0])
+	}
+	seeds := make(map[int64]bool)
+	for id, config := range configs {
+		if seeds[config.Options.Seed] {
+			t.Errorf("worker %d shares seed %d with another worker", id, config.Options.Seed)
+		}
+		seeds[config.Options.Seed] = true
+		if id == 0 {
+			continue
+		}
+
+		want := portfolio[(id-1)%len(portfolio)]
+		if id > len(portfolio) {
+			want.Options.Heuristic = RANDOM
+		}
+		got := config.Options
+		if config.Algorithm != want.Algorithm || got.Heuristic != want.Options.Heuristic ||
+			got.Restart != want.Options.Restart || got.Polarity != want.Options.Polarity {
+			t.Errorf("worker %d searches with %s, want %s", id, config, want)
+		}
+		if got.RestartInterval != 7 || got.ReduceInterval != 30 || got.MaxConflicts != 500 {
+			t.Errorf("worker %d did not keep the intervals and budgets: %+v", id, got)
+		}
+	}
+}
+
+func TestPortfolioSolvesExamples(t *testing.T) {
+	tests := []struct {
+		folder string
+		want   Result
+	}{
+		{"uf20-91", SATISFIABLE},
+		{"uf50-218", SATISFIABLE},
+		{"uuf50-218", UNSATISFIABLE},
+	}
+	for _, tt := range tests {
+		for _, file := range exampleFiles(t, tt.folder) {
+			task := loadTask(t, file)
+			for _, workers := range []int{1, 2, 4} {
+				name := fmt.Sprintf("%s with %d workers", filepath.Base(file), workers)
+				ps := NewPortfolioSolver(task, PortfolioConfigs(workers, DPLL, DefaultOptions()))
+				result, solution := ps.Solve(context.Background())
+				if result != tt.want {
+					t.Fatalf("%s: result %s, want %s", name, result, tt.want)
+				}
+				if ps.Winner < 0 || ps.Winner >= workers || ps.Stats.Workers != workers {
+					t.Errorf("%s: winner %d, %d workers counted", name, ps.Winner, ps.Stats.Workers)
+				}
+				if result != SATISFIABLE {
+					continue
+				}
+				if err := Verify(task, solution); err != nil {
+					t.Errorf("%s: solution rejected: %v", name, err)
+				}
+				if err := Verify(task, ClauseFromLits(ps.Model())); err != nil {
+					t.Errorf("%s: model rejected: %v", name, err)
+				}
+			}
+		}
+	}
+}
+
+// pigeonholeOrEscape returns the clauses putting n+1 pigeons into n holes,
+// each extended by an escape variable that satisfies all of them. Two more
+// clauses with variables of their own keep the escape from being a pure
+// literal. Deciding the escape true solves the task right away, deciding it
+// false leaves a search DPLL does not finish in reasonable time.
+func pigeonholeOrEscape(n int) [][]Lit {
+	pigeon := func(p, h int) int { return p*n + h + 1 }
+	escape := pos((n+1)*n + 1)
+	y, z := (n+1)*n+2, (n+1)*n+3
+	clauses := [][]Lit{{escape.Not(), pos(y), pos(z)}, {escape.Not(), neg(y), neg(z)}}
+	for p := 0; p <= n; p++ {
+		clause := []Lit{escape}
+		for h := 0; h < n; h++ {
+			clause = append(clause, pos(pigeon(p, h)))
+		}
+		clauses = append(clauses, clause)
+	}
+	for h := 0; h < n; h++ {
+		for p := 0; p <= n; p++ {
+			for q := p + 1; q <= n; q++ {
+				clauses = append(clauses, []Lit{escape, neg(pigeon(p, h)), neg(pigeon(q, h))})
+			}
+		}
+	}
+	return clauses
+}
+
+func TestPortfolioFirstFinisherCancelsOthers(t *testing.T) {
+	const holes = 12
+	task := taskFromLits((holes+1)*holes+3, pigeonholeOrEscape(holes))
+
+	// The escape variable occurs most often, so both workers decide it first
+	fast, slow := DefaultOptions(), DefaultOptions()
+	fast.Polarity, slow.Polarity = PositivePolarity, NegativePolarity
+	ps := NewPortfolioSolver(task, []PortfolioConfig{{DPLL, slow}, {DPLL, fast}})
+
+	start := time.Now()
+	result, solution := ps.Solve(context.Background())
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Solve took %v, the slow worker was not cancelled", elapsed)
+	}
+	if result != SATISFIABLE || ps.Winner != 1 {
+		t.Fatalf("result %s from worker %d, want %s from worker 1", result, ps.Winner, SATISFIABLE)
+	}
+	if err := Verify(task, solution); err != nil {
+		t.Errorf("solution rejected: %v", err)
+	}
+	if ps.stats[0].Decisions == 0 {
+		t.Error("the slow worker did not search before it was cancelled")
+	}
+}
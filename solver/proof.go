@@ -0,0 +1,96 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type ProofFormat int
+
+const (
+	TextProof   ProofFormat = iota // One clause per line, deletions prefixed with 'd'
+	BinaryProof                    // Compact byte encoding understood by the same checkers
+)
+
+func (f ProofFormat) String() string {
+	return [...]string{"text", "binary"}[f]
+}
+
+// ParseProofFormat converts a string to a ProofFormat
+func ParseProofFormat(formatStr string) (ProofFormat, error) {
+	for format := TextProof; format <= BinaryProof; format++ {
+		if format.String() == formatStr {
+			return format, nil
+		}
+	}
+	return TextProof, fmt.Errorf("unknown proof format '%s', expected 'text' or 'binary'", formatStr)
+}
+
+// ProofWriter writes a DRAT certificate: every clause the solver derives is
+// added, clauses it no longer needs are deleted again. A checker verifies an
+// UNSAT answer by confirming each added clause follows from the clauses
+// before it, up to the final empty clause.
+type ProofWriter struct {
+	out    *bufio.Writer
+	format ProofFormat
+	buf    []byte
+}
+
+func NewProofWriter(w io.Writer, format ProofFormat) *ProofWriter {
+	return &ProofWriter{
+		out:    bufio.NewWriter(w),
+		format: format,
+	}
+}
+
+// Add records a derived clause
+func (p *ProofWriter) Add(lits []Lit) {
+	p.write('a', lits)
+}
+
+// Delete records that a clause is not used anymore
+func (p *ProofWriter) Delete(lits []Lit) {
+	p.write('d', lits)
+}
+
+// Flush writes buffered steps and returns the first write error, if any
+func (p *ProofWriter) Flush() error {
+	return p.out.Flush()
+}
+
+func (p *ProofWriter) write(kind byte, lits []Lit) {
+	p.buf = p.buf[:0]
+
+	if p.format == BinaryProof {
+		// The packed literal (variable * 2 + sign) is exactly the binary DRAT
+		// literal, written as a little endian base-128 number
+		p.buf = append(p.buf, kind)
+		for _, lit := range lits {
+			for u := uint32(lit); ; u >>= 7 {
+				if u < 0x80 {
+					p.buf = append(p.buf, byte(u))
+					break
+				}
+				p.buf = append(p.buf, byte(u&0x7f|0x80))
+			}
+		}
+		p.buf = append(p.buf, 0)
+	} else {
+		if kind == 'd' {
+			p.buf = append(p.buf, 'd', ' ')
+		}
+		for _, lit := range lits {
+			if lit.Negated() {
+				p.buf = append(p.buf, '-')
+			}
+			p.buf = strconv.AppendInt(p.buf, int64(lit.Var()), 10)
+			p.buf = append(p.buf, ' ')
+		}
+		p.buf = append(p.buf, '0', '\n')
+	}
+
+	// Write errors are kept by the buffered writer and reported by Flush
+	p.out.Write(p.buf)
+}
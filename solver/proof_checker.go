@@ -0,0 +1,821 @@
+package solver
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+type ProofKind int
+
+const (
+	DRAT ProofKind = iota // Clause additions and deletions, checked by reverse unit propagation
+	LRAT                  // Numbered clauses with the hints needed to check every addition
+)
+
+func (k ProofKind) String() string {
+	return [...]string{"drat", "lrat"}[k]
+}
+
+// ParseProofKind converts a string to a ProofKind
+func ParseProofKind(kindStr string) (ProofKind, error) {
+	for kind := DRAT; kind <= LRAT; kind++ {
+		if kind.String() == kindStr {
+			return kind, nil
+		}
+	}
+	return DRAT, fmt.Errorf("unknown proof kind '%s', expected 'drat' or 'lrat'", kindStr)
+}
+
+// ProofChecker verifies that a proof refutes the clauses of a task, i.e. that
+// the task is unsatisfiable. Text and binary proofs are told apart by their
+// content.
+type ProofChecker struct {
+	Task      *parser.Task
+	Additions int // Clauses added by the proof
+	Deletions int // Clauses deleted by the proof
+	Checked   int // Added clauses which had to be checked
+	RAT       int // Added clauses which needed the resolution asymmetric tautology check
+	Ignored   int // Deletions of unit clauses, which are ignored like most checkers do
+
+	clauses *ClauseDB
+	active  []bool // Per clause: part of the clause set at the current proof step
+	core    []bool // Per clause: needed to derive the empty clause
+	units   []int  // Clauses with a single literal
+	pivots  []Lit  // Per clause: first literal as written, propagation reorders the stored ones
+
+	assigns []int8
+	reasons []int
+	trail   []Lit
+	watches [][]int
+}
+
+func NewProofChecker(task *parser.Task) *ProofChecker {
+	c := &ProofChecker{
+		Task:    task,
+		clauses: NewClauseDB(&parser.Task{NumVars: task.NumVars}),
+	}
+	c.reserveVars(task.NumVars)
+
+	lits := make([]Lit, 0)
+	for _, clause := range task.Clauses {
+		lits = lits[:0]
+		for _, cVar := range clause.Vars {
+			lits = append(lits, LitFromVariable(cVar))
+		}
+		c.addClause(lits)
+	}
+	return c
+}
+
+// proofStep is a single addition or deletion of a DRAT proof
+type proofStep struct {
+	clauseID int
+	deletion bool
+}
+
+// CheckDRAT verifies a DRAT proof by backward checking. All steps are applied
+// until the empty clause is added; then, going backwards, every added clause
+// the refutation depends on is checked to follow from the clauses before it
+// by reverse unit propagation (RUP), or else to be a resolution asymmetric
+// tautology (RAT) on its first literal. Clauses not needed for the refutation
+// are skipped. A proof without the empty clause is accepted if the clauses
+// propagate to a conflict after its last step.
+func (c *ProofChecker) CheckDRAT(proof io.Reader) error {
+	data, err := io.ReadAll(proof)
+	if err != nil {
+		return fmt.Errorf("failed to read proof: %v", err)
+	}
+
+	// Clauses are found for deletion by their sorted literals
+	index := make(map[string][]int)
+	for clauseID := 0; clauseID < c.clauses.Len(); clauseID++ {
+		key := clauseKey(c.clauses.Lits(clauseID))
+		index[key] = append(index[key], clauseID)
+	}
+
+	steps := make([]proofStep, 0)
+	refuted := false
+	err = readDRAT(data, func(deletion bool, lits []Lit) error {
+		key := clauseKey(lits)
+		if !deletion {
+			clauseID := c.addClause(lits)
+			c.Additions++
+			index[key] = append(index[key], clauseID)
+			steps = append(steps, proofStep{clauseID: clauseID})
+			refuted = len(lits) == 0
+			return nil
+		}
+
+		c.Deletions++
+		candidates := index[key]
+		if len(candidates) == 0 {
+			return fmt.Errorf("deleted clause %s does not exist", ClauseFromLits(lits))
+		}
+		clauseID := candidates[len(candidates)-1]
+		if c.clauses.headers[clauseID].size == 1 {
+			c.Ignored++
+			return nil
+		}
+		index[key] = candidates[:len(candidates)-1]
+		c.active[clauseID] = false
+		steps = append(steps, proofStep{clauseID: clauseID, deletion: true})
+		return nil
+	}, func() bool { return refuted })
+	if err != nil {
+		return err
+	}
+
+	// The final clause set has to be refuted by unit propagation
+	if refuted {
+		last := steps[len(steps)-1]
+		steps = steps[:len(steps)-1]
+		c.active[last.clauseID] = false
+	}
+	if !c.refutes(nil) {
+		if refuted {
+			return fmt.Errorf("the empty clause does not follow by unit propagation")
+		}
+		return fmt.Errorf("the clauses do not propagate to a conflict at the end of the proof")
+	}
+
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if step.deletion {
+			c.active[step.clauseID] = true
+			continue
+		}
+
+		c.active[step.clauseID] = false
+		if !c.core[step.clauseID] {
+			continue
+		}
+		c.Checked++
+		if err := c.checkLemma(step.clauseID); err != nil {
+			return fmt.Errorf("addition %d: %v", c.additionNumber(steps, i), err)
+		}
+	}
+
+	return nil
+}
+
+// additionNumber counts the additions up to the given step, starting at 1
+func (c *ProofChecker) additionNumber(steps []proofStep, step int) int {
+	count := 0
+	for _, s := range steps[:step+1] {
+		if !s.deletion {
+			count++
+		}
+	}
+	return count
+}
+
+// checkLemma checks an added clause against the active clauses, first by RUP
+// and then by RAT on its first literal
+func (c *ProofChecker) checkLemma(clauseID int) error {
+	lemma := c.clauses.Lits(clauseID)
+	negated := make([]Lit, len(lemma))
+	for i, lit := range lemma {
+		negated[i] = lit.Not()
+	}
+	if c.refutes(negated) {
+		return nil
+	}
+	if len(lemma) == 0 {
+		return fmt.Errorf("the empty clause does not follow by unit propagation")
+	}
+
+	// Every resolvent on the pivot has to be an asymmetric tautology
+	c.RAT++
+	pivot := c.pivots[clauseID]
+	for other := 0; other < c.clauses.Len(); other++ {
+		if !c.active[other] || !slices.Contains(c.clauses.Lits(other), pivot.Not()) {
+			continue
+		}
+		resolvent := append([]Lit{}, negated...)
+		for _, lit := range c.clauses.Lits(other) {
+			if lit != pivot.Not() {
+				resolvent = append(resolvent, lit.Not())
+			}
+		}
+		if !c.refutes(resolvent) {
+			return fmt.Errorf("clause %s is neither RUP nor RAT on %s", ClauseFromLits(lemma), pivot)
+		}
+		c.core[other] = true
+	}
+	return nil
+}
+
+// refutes assigns the given literals and reports whether unit propagation over
+// the active clauses runs into a conflict. The clauses involved in the
+// conflict are marked as core.
+func (c *ProofChecker) refutes(assumed []Lit) bool {
+	defer c.reset()
+
+	for _, lit := range assumed {
+		c.reserveVars(lit.Var())
+		switch c.value(lit) {
+		case -1:
+			// The literals contradict each other without any clause
+			return true
+		case 0:
+			c.assign(lit, -1)
+		}
+	}
+
+	for _, clauseID := range c.units {
+		if !c.active[clauseID] {
+			continue
+		}
+		lit := c.clauses.Lits(clauseID)[0]
+		switch c.value(lit) {
+		case -1:
+			c.markCore(clauseID)
+			return true
+		case 0:
+			c.assign(lit, clauseID)
+		}
+	}
+
+	if conflict := c.propagate(); conflict >= 0 {
+		c.markCore(conflict)
+		return true
+	}
+	return false
+}
+
+// markCore marks the conflicting clause and the reasons of all assignments
+// that lead to it
+func (c *ProofChecker) markCore(conflict int) {
+	seen := make([]bool, len(c.assigns))
+	c.core[conflict] = true
+	for _, lit := range c.clauses.Lits(conflict) {
+		seen[lit.Var()] = true
+	}
+	for i := len(c.trail) - 1; i >= 0; i-- {
+		id := c.trail[i].Var()
+		if !seen[id] || c.reasons[id] < 0 {
+			continue
+		}
+		c.core[c.reasons[id]] = true
+		for _, lit := range c.clauses.Lits(c.reasons[id]) {
+			seen[lit.Var()] = true
+		}
+	}
+}
+
+func (c *ProofChecker) addClause(lits []Lit) int {
+	clauseID := c.clauses.Add(lits)
+	c.reserveVars(c.clauses.NumVars)
+	c.active = append(c.active, true)
+	c.core = append(c.core, false)
+	if len(lits) > 0 {
+		c.pivots = append(c.pivots, lits[0])
+	} else {
+		c.pivots = append(c.pivots, 0)
+	}
+
+	stored := c.clauses.Lits(clauseID)
+	switch len(stored) {
+	case 0:
+	case 1:
+		c.units = append(c.units, clauseID)
+	default:
+		c.watches[stored[0]] = append(c.watches[stored[0]], clauseID)
+		c.watches[stored[1]] = append(c.watches[stored[1]], clauseID)
+	}
+	return clauseID
+}
+
+func (c *ProofChecker) reserveVars(numVars int) {
+	c.clauses.NumVars = max(c.clauses.NumVars, numVars)
+	for len(c.assigns) <= c.clauses.NumVars {
+		c.assigns = append(c.assigns, 0)
+		c.reasons = append(c.reasons, -1)
+	}
+	for len(c.watches) < 2*c.clauses.NumVars+2 {
+		c.watches = append(c.watches, nil)
+	}
+}
+
+func (c *ProofChecker) value(lit Lit) int8 {
+	if lit.Negated() {
+		return -c.assigns[lit.Var()]
+	}
+	return c.assigns[lit.Var()]
+}
+
+func (c *ProofChecker) assign(lit Lit, reason int) {
+	if lit.Negated() {
+		c.assigns[lit.Var()] = -1
+	} else {
+		c.assigns[lit.Var()] = 1
+	}
+	c.reasons[lit.Var()] = reason
+	c.trail = append(c.trail, lit)
+}
+
+func (c *ProofChecker) reset() {
+	for _, lit := range c.trail {
+		c.assigns[lit.Var()] = 0
+		c.reasons[lit.Var()] = -1
+	}
+	c.trail = c.trail[:0]
+}
+
+// propagate works like the solvers' watched literal propagation, except that
+// clauses which are not active are skipped but keep their watches. Nothing is
+// assigned between two checks, so the watches stay valid while a clause is
+// inactive.
+func (c *ProofChecker) propagate() int {
+	for qhead := 0; qhead < len(c.trail); qhead++ {
+		falseLit := c.trail[qhead].Not()
+		watchList := c.watches[falseLit]
+		kept := watchList[:0]
+		conflict := -1
+
+		for i := 0; i < len(watchList); i++ {
+			clauseID := watchList[i]
+			if !c.active[clauseID] {
+				kept = append(kept, clauseID)
+				continue
+			}
+
+			lits := c.clauses.Lits(clauseID)
+			if lits[0] == falseLit {
+				lits[0], lits[1] = lits[1], lits[0]
+			}
+			if c.value(lits[0]) == 1 {
+				kept = append(kept, clauseID)
+				continue
+			}
+
+			moved := false
+			for k := 2; k < len(lits); k++ {
+				if c.value(lits[k]) != -1 {
+					lits[1], lits[k] = lits[k], lits[1]
+					c.watches[lits[1]] = append(c.watches[lits[1]], clauseID)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+
+			kept = append(kept, clauseID)
+			if c.value(lits[0]) == -1 {
+				conflict = clauseID
+				kept = append(kept, watchList[i+1:]...)
+				break
+			}
+			c.assign(lits[0], clauseID)
+		}
+
+		c.watches[falseLit] = kept
+		if conflict >= 0 {
+			return conflict
+		}
+	}
+	return -1
+}
+
+// CheckLRAT verifies an LRAT proof. Every added clause lists the clauses that
+// become unit, in order, once its literals are assumed false, until one of
+// them is falsified. Negative hints introduce the clauses of a RAT check.
+// Clauses of the task are numbered from 1 in file order.
+func (c *ProofChecker) CheckLRAT(proof io.Reader) error {
+	data, err := io.ReadAll(proof)
+	if err != nil {
+		return fmt.Errorf("failed to read proof: %v", err)
+	}
+
+	clauses := make(map[int][]Lit)
+	for clauseID := 0; clauseID < c.clauses.Len(); clauseID++ {
+		clauses[clauseID+1] = c.clauses.Lits(clauseID)
+	}
+
+	refuted := false
+	err = readLRAT(data, func(clauseID int, lits []Lit, hints []int) error {
+		c.Additions++
+		c.Checked++
+		for _, lit := range lits {
+			c.reserveVars(lit.Var())
+		}
+		if err := c.checkHints(clauses, lits, hints); err != nil {
+			return fmt.Errorf("clause %d: %v", clauseID, err)
+		}
+		clauses[clauseID] = append([]Lit{}, lits...)
+		refuted = refuted || len(lits) == 0
+		return nil
+	}, func(ids []int) error {
+		for _, id := range ids {
+			c.Deletions++
+			delete(clauses, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !refuted {
+		return fmt.Errorf("the proof does not add the empty clause")
+	}
+	return nil
+}
+
+// checkHints follows the hints of an LRAT addition
+func (c *ProofChecker) checkHints(clauses map[int][]Lit, lemma []Lit, hints []int) error {
+	defer c.reset()
+
+	for _, lit := range lemma {
+		switch c.value(lit) {
+		case 1:
+			// The clause contains a literal and its negation
+			return nil
+		case 0:
+			c.assign(lit.Not(), -1)
+		}
+	}
+
+	// Hints before the first negative one are shared by all RAT candidates
+	common := len(hints)
+	for i, hint := range hints {
+		if hint < 0 {
+			common = i
+			break
+		}
+	}
+	conflict, err := c.followHints(clauses, hints[:common])
+	if err != nil || conflict {
+		return err
+	}
+	if len(lemma) == 0 {
+		return fmt.Errorf("hints do not lead to a conflict")
+	}
+
+	// Without a conflict the clause has to be RAT on its first literal
+	c.RAT++
+	groups := make(map[int][]int)
+	for i := common; i < len(hints); {
+		candidate := -hints[i]
+		end := i + 1
+		for end < len(hints) && hints[end] > 0 {
+			end++
+		}
+		groups[candidate] = hints[i+1 : end]
+		i = end
+	}
+
+	pivot := lemma[0]
+	mark := len(c.trail)
+	ids := make([]int, 0, len(clauses))
+	for id := range clauses {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		candidate := clauses[id]
+		if !slices.Contains(candidate, pivot.Not()) {
+			continue
+		}
+
+		// A resolvent containing a literal and its negation needs no hints
+		tautology := false
+		for _, lit := range candidate {
+			if lit != pivot.Not() && slices.Contains(lemma, lit.Not()) {
+				tautology = true
+			}
+		}
+		if tautology {
+			continue
+		}
+
+		group, ok := groups[id]
+		if !ok && len(groups) == 0 {
+			return fmt.Errorf("hints do not lead to a conflict")
+		}
+		if !ok {
+			return fmt.Errorf("no RAT hints for clause %d", id)
+		}
+		conflict := false
+		for _, lit := range candidate {
+			if lit == pivot.Not() {
+				continue
+			}
+			switch c.value(lit) {
+			case 1:
+				conflict = true
+			case 0:
+				c.assign(lit.Not(), -1)
+			}
+		}
+		if !conflict {
+			conflict, err = c.followHints(clauses, group)
+			if err != nil {
+				return err
+			}
+		}
+		if !conflict {
+			return fmt.Errorf("RAT hints for clause %d do not lead to a conflict", id)
+		}
+
+		// Undo the resolvent's assignments for the next candidate
+		for _, lit := range c.trail[mark:] {
+			c.assigns[lit.Var()] = 0
+		}
+		c.trail = c.trail[:mark]
+	}
+	return nil
+}
+
+// followHints assigns the unit literal of every hinted clause and reports
+// whether one of them is falsified
+func (c *ProofChecker) followHints(clauses map[int][]Lit, hints []int) (bool, error) {
+	for _, hint := range hints {
+		clause, ok := clauses[hint]
+		if !ok {
+			return false, fmt.Errorf("hint %d refers to a missing clause", hint)
+		}
+		unit, open := Lit(0), 0
+		for _, lit := range clause {
+			switch c.value(lit) {
+			case 1:
+				return false, fmt.Errorf("hint %d is satisfied", hint)
+			case 0:
+				unit = lit
+				open++
+			}
+		}
+		switch open {
+		case 0:
+			return true, nil
+		case 1:
+			c.assign(unit, -1)
+		default:
+			return false, fmt.Errorf("hint %d is not unit", hint)
+		}
+	}
+	return false, nil
+}
+
+// clauseKey identifies a clause independent of the order of its literals
+func clauseKey(lits []Lit) string {
+	sorted := slices.Clone(lits)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+	key := make([]byte, 0, 4*len(sorted))
+	for _, lit := range sorted {
+		key = strconv.AppendUint(key, uint64(lit), 36)
+		key = append(key, ' ')
+	}
+	return string(key)
+}
+
+// isBinaryProof guesses the proof encoding: text proofs only contain digits,
+// signs, whitespace, comments and deletion markers
+func isBinaryProof(data []byte) bool {
+	for _, b := range data[:min(len(data), 256)] {
+		if b != '\n' && b != '\r' && b != '\t' && (b < ' ' || b > '~') {
+			return true
+		}
+	}
+	return false
+}
+
+// readDRAT calls step for every addition and deletion of a text or binary
+// DRAT proof, until done reports true
+func readDRAT(data []byte, step func(deletion bool, lits []Lit) error, done func() bool) error {
+	if isBinaryProof(data) {
+		reader := &binaryReader{data: data}
+		for !reader.eof() && !done() {
+			kind := reader.data[reader.pos]
+			reader.pos++
+			if kind != 'a' && kind != 'd' {
+				return fmt.Errorf("unexpected byte 0x%02x at position %d of binary proof", kind, reader.pos-1)
+			}
+			numbers, err := reader.numbers()
+			if err != nil {
+				return err
+			}
+			lits := make([]Lit, len(numbers))
+			for i, u := range numbers {
+				lits[i] = Lit(u)
+			}
+			if err := step(kind == 'd', lits); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return readTextLines(data, func(line int, fields []string) error {
+		if done() {
+			return nil
+		}
+		deletion := fields[0] == "d"
+		if deletion {
+			fields = fields[1:]
+		}
+		numbers, err := parseNumbers(fields)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		if len(numbers) == 0 || numbers[len(numbers)-1] != 0 {
+			return fmt.Errorf("line %d: clause does not end with a 0", line)
+		}
+		if err := step(deletion, litsFromNumbers(numbers[:len(numbers)-1])); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		return nil
+	})
+}
+
+// readLRAT calls add for every addition and remove for every deletion of a
+// text or binary LRAT proof
+func readLRAT(data []byte, add func(clauseID int, lits []Lit, hints []int) error, remove func(ids []int) error) error {
+	if isBinaryProof(data) {
+		reader := &binaryReader{data: data}
+		for !reader.eof() {
+			kind := reader.data[reader.pos]
+			reader.pos++
+			if kind != 'a' && kind != 'd' {
+				return fmt.Errorf("unexpected byte 0x%02x at position %d of binary proof", kind, reader.pos-1)
+			}
+
+			// Clause IDs and hints are encoded like literals, the sign in the
+			// lowest bit
+			var err error
+			if kind == 'a' {
+				var clauseID uint64
+				var lits, hints []uint64
+				if clauseID, err = reader.number(); err != nil {
+					return err
+				}
+				if lits, err = reader.numbers(); err != nil {
+					return err
+				}
+				if hints, err = reader.numbers(); err != nil {
+					return err
+				}
+				packed := make([]Lit, len(lits))
+				for i, u := range lits {
+					packed[i] = Lit(u)
+				}
+				err = add(int(clauseID>>1), packed, signedNumbers(hints))
+			} else {
+				var ids []uint64
+				if ids, err = reader.numbers(); err != nil {
+					return err
+				}
+				err = remove(signedNumbers(ids))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return readTextLines(data, func(line int, fields []string) error {
+		if len(fields) >= 2 && fields[1] == "d" {
+			ids, err := parseNumbers(fields[2:])
+			if err != nil {
+				return fmt.Errorf("line %d: %v", line, err)
+			}
+			if len(ids) == 0 || ids[len(ids)-1] != 0 {
+				return fmt.Errorf("line %d: deletion does not end with a 0", line)
+			}
+			return remove(ids[:len(ids)-1])
+		}
+
+		numbers, err := parseNumbers(fields)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		end := slices.Index(numbers[1:], 0) + 1
+		if end == 0 || end == len(numbers)-1 || numbers[len(numbers)-1] != 0 {
+			return fmt.Errorf("line %d: expected '<id> <literals> 0 <hints> 0'", line)
+		}
+		if err := add(numbers[0], litsFromNumbers(numbers[1:end]), numbers[end+1:len(numbers)-1]); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		return nil
+	})
+}
+
+// readTextLines calls fn with the fields of every line that is neither empty
+// nor a comment
+func readTextLines(data []byte, fn func(line int, fields []string) error) error {
+	line := 0
+	for start := 0; start < len(data); {
+		end := start
+		for end < len(data) && data[end] != '\n' {
+			end++
+		}
+		line++
+		fields := splitFields(data[start:end])
+		start = end + 1
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+		if err := fn(line, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitFields(line []byte) []string {
+	fields := make([]string, 0)
+	for i := 0; i < len(line); {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t' || line[i] == '\r') {
+			i++
+		}
+		start := i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' && line[i] != '\r' {
+			i++
+		}
+		if i > start {
+			fields = append(fields, string(line[start:i]))
+		}
+	}
+	return fields
+}
+
+func parseNumbers(fields []string) ([]int, error) {
+	numbers := make([]int, len(fields))
+	for i, field := range fields {
+		number, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected token %s, expected integer", field)
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+// litsFromNumbers converts DIMACS literals to packed literals
+func litsFromNumbers(numbers []int) []Lit {
+	lits := make([]Lit, len(numbers))
+	for i, number := range numbers {
+		if number < 0 {
+			lits[i] = MkLit(-number, true)
+		} else {
+			lits[i] = MkLit(number, false)
+		}
+	}
+	return lits
+}
+
+// signedNumbers decodes binary LRAT numbers (value * 2 + sign)
+func signedNumbers(values []uint64) []int {
+	numbers := make([]int, len(values))
+	for i, u := range values {
+		numbers[i] = int(u >> 1)
+		if u&1 == 1 {
+			numbers[i] = -numbers[i]
+		}
+	}
+	return numbers
+}
+
+// binaryReader decodes the little endian base-128 numbers of binary proofs
+type binaryReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *binaryReader) eof() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *binaryReader) number() (uint64, error) {
+	var u uint64
+	for shift := 0; ; shift += 7 {
+		if r.eof() || shift > 63 {
+			return 0, fmt.Errorf("truncated number at position %d of binary proof", r.pos)
+		}
+		b := r.data[r.pos]
+		r.pos++
+		u |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return u, nil
+		}
+	}
+}
+
+// numbers reads numbers up to the terminating 0
+func (r *binaryReader) numbers() ([]uint64, error) {
+	numbers := make([]uint64, 0)
+	for {
+		u, err := r.number()
+		if err != nil || u == 0 {
+			return numbers, err
+		}
+		numbers = append(numbers, u)
+	}
+}
@@ -0,0 +1,88 @@
+package solver
+
+import (
+	"strings"
+	"testing"
+)
+
+// allSigns has every clause over variables 1 and 2, so it is unsatisfiable
+var allSigns = [][]Lit{{pos(1), pos(2)}, {pos(1), neg(2)}, {neg(1), pos(2)}, {neg(1), neg(2)}}
+
+func TestCheckDRAT(t *testing.T) {
+	// Only satisfiable with 1 true and 2 false
+	satisfiable := [][]Lit{{pos(1), pos(2)}, {neg(1), neg(2)}, {pos(1), neg(2)}}
+	// -2 is no RUP lemma, but every resolvent on it is
+	needsRAT := [][]Lit{
+		{neg(2), neg(1), pos(3)}, {pos(2), neg(3)}, {pos(1), pos(3), neg(2)},
+		{neg(2), pos(1), neg(3)}, {neg(1), neg(3)}, {pos(3), pos(2)},
+	}
+
+	tests := []struct {
+		name    string
+		clauses [][]Lit
+		proof   string
+		ok      bool
+		rat     int
+	}{
+		{"RUP", allSigns, "1 0\n0\n", true, 0},
+		{"RAT", needsRAT, "-2 0\n0\n", true, 1},
+		{"without empty clause", allSigns, "1 0\n", true, 0},
+		{"unused wrong lemma", allSigns, "3 0\n1 0\n0\n", true, 0},
+		{"deletions", allSigns, "1 0\nd 1 2 0\nd 1 -2 0\n0\n", true, 0},
+		{"comments", allSigns, "c derive 1\n1 0\n0\n", true, 0},
+		{"binary", allSigns, "a\x02\x00a\x00", true, 0},
+		{"wrong lemma", satisfiable, "-1 0\n0\n", false, 1},
+		{"empty clause only", allSigns, "0\n", false, 0},
+		{"needed clause deleted", allSigns, "d -1 2 0\n1 0\n0\n", false, 0},
+		{"unknown deletion", allSigns, "d 1 3 0\n1 0\n0\n", false, 0},
+		{"no conflict at the end", satisfiable, "1 0\n", false, 0},
+		{"malformed", allSigns, "1 x 0\n0\n", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewProofChecker(taskFromLits(3, tt.clauses))
+			err := checker.CheckDRAT(strings.NewReader(tt.proof))
+			if (err == nil) != tt.ok {
+				t.Fatalf("CheckDRAT(%q) = %v, want ok: %v", tt.proof, err, tt.ok)
+			}
+			if checker.RAT != tt.rat {
+				t.Errorf("%d RAT checks, want %d", checker.RAT, tt.rat)
+			}
+		})
+	}
+}
+
+func TestCheckLRAT(t *testing.T) {
+	tests := []struct {
+		name  string
+		proof string
+		ok    bool
+	}{
+		{"refutation", "5 1 0 1 2 0\n6 0 5 3 4 0\n", true},
+		{"deletions", "5 1 0 1 2 0\n5 d 1 2 0\n6 0 5 3 4 0\n", true},
+		{"hints without conflict", "5 1 0 1 2 0\n6 0 5 3 0\n", false},
+		{"satisfied hint", "5 1 0 3 0\n6 0 5 3 4 0\n", false},
+		{"deleted hint", "5 1 0 1 2 0\n5 d 3 0\n6 0 5 3 4 0\n", false},
+		{"missing empty clause", "5 1 0 1 2 0\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewProofChecker(taskFromLits(2, allSigns))
+			err := checker.CheckLRAT(strings.NewReader(tt.proof))
+			if (err == nil) != tt.ok {
+				t.Errorf("CheckLRAT(%q) = %v, want ok: %v", tt.proof, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseProofKind(t *testing.T) {
+	for _, kind := range []string{"drat", "lrat"} {
+		if got, err := ParseProofKind(kind); err != nil || got.String() != kind {
+			t.Errorf("ParseProofKind(%q) = %v, %v", kind, got, err)
+		}
+	}
+	if _, err := ParseProofKind("text"); err == nil {
+		t.Error("unknown proof kind accepted")
+	}
+}
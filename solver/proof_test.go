@@ -0,0 +1,134 @@
+package solver
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+func TestProofWriterFormats(t *testing.T) {
+	tests := []struct {
+		format ProofFormat
+		want   string
+	}{
+		{TextProof, "1 -2 300 0\nd -1 0\n0\n"},
+		// 1 -> 2, -2 -> 5, 300 -> 600 = 0xd8 0x04, -1 -> 3
+		{BinaryProof, "a\x02\x05\xd8\x04\x00d\x03\x00a\x00"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		p := NewProofWriter(&out, tt.format)
+		p.Add([]Lit{pos(1), neg(2), pos(300)})
+		p.Delete([]Lit{neg(1)})
+		p.Add(nil)
+		if err := p.Flush(); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("%s: wrote %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestParseProofFormat(t *testing.T) {
+	for _, format := range []ProofFormat{TextProof, BinaryProof} {
+		if got, err := ParseProofFormat(format.String()); err != nil || got != format {
+			t.Errorf("ParseProofFormat(%q) = %v, %v", format.String(), got, err)
+		}
+	}
+	if _, err := ParseProofFormat("lrat"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+// proofSolvers runs each solver that writes proofs on a task and returns
+// its result
+var proofSolvers = map[string]func(task *parser.Task, proof *ProofWriter) Result{
+	"dpll": func(task *parser.Task, proof *ProofWriter) Result {
+		s := NewSolver(task, DefaultOptions())
+		s.Proof = proof
+		s.Solve(context.Background())
+		return s.Result
+	},
+	"lookahead": func(task *parser.Task, proof *ProofWriter) Result {
+		s := NewLookaheadSolver(task, DefaultOptions())
+		s.Proof = proof
+		s.Solve(context.Background())
+		return s.Result
+	},
+	"cdcl": func(task *parser.Task, proof *ProofWriter) Result {
+		options := DefaultOptions()
+		options.ReduceInterval = 2
+		s := NewCDCLSolver(task, options)
+		s.Proof = proof
+		s.Solve(context.Background())
+		return s.Result
+	},
+}
+
+func TestSolverProofsEndWithEmptyClause(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+	for name, solve := range proofSolvers {
+		var out bytes.Buffer
+		proof := NewProofWriter(&out, TextProof)
+		if result := solve(task, proof); result != UNSATISFIABLE {
+			t.Fatalf("%s: result %s", name, result)
+		}
+		if err := proof.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+		if len(lines) < 2 {
+			t.Errorf("%s: proof without lemmas: %q", name, out.String())
+		}
+		if last := string(lines[len(lines)-1]); last != "0" {
+			t.Errorf("%s: proof ends with %q instead of the empty clause", name, last)
+		}
+	}
+}
+
+func TestSolverProofsAreAccepted(t *testing.T) {
+	for _, file := range exampleFiles(t, "uuf50-218") {
+		task := loadTask(t, file)
+		for name, solve := range proofSolvers {
+			for _, format := range []ProofFormat{TextProof, BinaryProof} {
+				var out bytes.Buffer
+				proof := NewProofWriter(&out, format)
+				if result := solve(task, proof); result != UNSATISFIABLE {
+					t.Fatalf("%s on %s: result %s", name, filepath.Base(file), result)
+				}
+				if err := proof.Flush(); err != nil {
+					t.Fatal(err)
+				}
+
+				checker := NewProofChecker(task)
+				if err := checker.CheckDRAT(&out); err != nil {
+					t.Errorf("%s %s proof of %s rejected: %v", name, format, filepath.Base(file), err)
+				}
+			}
+		}
+	}
+}
+
+func TestTruncatedSolverProofIsRejected(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+	var out bytes.Buffer
+	proof := NewProofWriter(&out, TextProof)
+	if result := proofSolvers["cdcl"](task, proof); result != UNSATISFIABLE {
+		t.Fatalf("result %s", result)
+	}
+	proof.Flush()
+
+	// Only the empty clause is left, it does not follow from the task alone
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if last := string(lines[len(lines)-1]); last != "0" {
+		t.Fatalf("proof ends with %q instead of the empty clause", last)
+	}
+	if err := NewProofChecker(task).CheckDRAT(bytes.NewReader(lines[len(lines)-1])); err == nil {
+		t.Error("proof without the learned clauses accepted")
+	}
+}
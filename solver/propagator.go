@@ -0,0 +1,426 @@
+package solver
+
+import (
+	"sort"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// propagator performs unit propagation with two watched literals. Every clause
+// with at least two literals watches its first two; an assignment only visits
+// the clauses watching the literal it falsifies and either moves the watch to
+// another non-false literal or, if there is none, propagates the other
+// watched literal. Clauses are never removed during the search, instead all
+// assignments are recorded on a trail which is undone level by level when
+// backtracking.
+type propagator struct {
+	db       *ClauseDB // Clause arena, the first two literals of each clause are watched
+	assigns  []int8    // Per variable: 0 unassigned, 1 true, -1 false
+	levels   []int     // Decision level each variable was assigned at
+	reasons  []int     // Index of the clause that implied each variable, -1 for decisions
+	trail    []Lit     // Assigned literals in assignment order
+	trailLim []int     // Trail index at which each decision level starts
+	qheadLim []int     // Propagation position when each decision level started
+	watches  [][]int   // Per literal: the clauses currently watching that literal
+	qhead    int       // Next trail position to propagate
+	conflict int       // Clause found falsified while loading, -1 if none
+
+	propagations int // Literals assigned by propagate so far, over all calls
+
+	listener BacktrackListener // Notified about every undone assignment, may be nil
+}
+
+// newPropagator sets up watches for every clause of the database. The
+// propagator reorders the literals of the clauses, so every propagator needs
+// a database of its own.
+func newPropagator(db *ClauseDB) *propagator {
+	reasons := make([]int, db.NumVars+1)
+	for i := range reasons {
+		reasons[i] = -1
+	}
+
+	p := &propagator{
+		db:       db,
+		assigns:  make([]int8, db.NumVars+1),
+		levels:   make([]int, db.NumVars+1),
+		reasons:  reasons,
+		watches:  make([][]int, 2*db.NumVars+2),
+		conflict: -1,
+	}
+
+	for clauseID := 0; clauseID < db.Len(); clauseID++ {
+		p.watchClause(clauseID)
+	}
+
+	return p
+}
+
+// addLearned stores a learned clause and returns its index. The first two
+// literals of the clause become its watches, so learned clauses have to be
+// passed with the asserting literal first and the most recently falsified
+// literal second.
+func (p *propagator) addLearned(lits []Lit) int {
+	clauseID := p.db.AddLearned(lits)
+	p.watchClause(clauseID)
+	return clauseID
+}
+
+// addImported stores a clause learned by another solver under the current
+// assignment and returns its index and whether it is falsified by it. The
+// literals are ordered so the watches are valid: the ones not false first,
+// then the false ones assigned last. If only the first one is left, it is
+// assigned right away. A falsified clause on level 0 stays a conflict like in
+// addClause. Clauses with less than two literals must be added on level 0.
+func (p *propagator) addImported(lits []Lit) (int, bool) {
+	clauseID := p.db.AddLearned(lits)
+	p.reserveVars(p.db.NumVars)
+
+	stored := p.db.Lits(clauseID)
+	sort.SliceStable(stored, func(i, j int) bool {
+		vi, vj := p.Value(stored[i]), p.Value(stored[j])
+		if vi == -1 && vj == -1 {
+			return p.levels[stored[i].Var()] > p.levels[stored[j].Var()]
+		}
+		return vi > vj
+	})
+
+	falsified := len(stored) == 0 || p.Value(stored[0]) == -1
+	if len(stored) >= 2 && p.Value(stored[1]) == -1 {
+		switch p.Value(stored[0]) {
+		case 0:
+			p.assign(stored[0], clauseID)
+		case -1:
+			if p.decisionLevel() == 0 && p.conflict < 0 {
+				p.conflict = clauseID
+			}
+		}
+	}
+	p.watchClause(clauseID)
+	return clauseID, falsified
+}
+
+// addClause stores a clause of the problem at decision level 0 and returns its
+// index. Literals already assigned false are moved behind the others, so the
+// clause is propagated or reported as conflict right away if it has to be.
+func (p *propagator) addClause(lits []Lit) int {
+	clauseID := p.db.Add(lits)
+	p.reserveVars(p.db.NumVars)
+
+	stored := p.db.Lits(clauseID)
+	sort.SliceStable(stored, func(i, j int) bool {
+		return p.Value(stored[i]) > p.Value(stored[j])
+	})
+
+	if len(stored) >= 2 && p.Value(stored[1]) == -1 {
+		switch p.Value(stored[0]) {
+		case 0:
+			p.assign(stored[0], clauseID)
+		case -1:
+			if p.conflict < 0 {
+				p.conflict = clauseID
+			}
+		}
+	}
+	p.watchClause(clauseID)
+	return clauseID
+}
+
+// reserveVars extends the per-variable state for variable IDs up to numVars
+func (p *propagator) reserveVars(numVars int) {
+	p.db.NumVars = max(p.db.NumVars, numVars)
+	for len(p.assigns) <= p.db.NumVars {
+		p.assigns = append(p.assigns, 0)
+		p.levels = append(p.levels, 0)
+		p.reasons = append(p.reasons, -1)
+	}
+	for len(p.watches) < 2*p.db.NumVars+2 {
+		p.watches = append(p.watches, nil)
+	}
+}
+
+func (p *propagator) watchClause(clauseID int) {
+	lits := p.db.Lits(clauseID)
+
+	switch len(lits) {
+	case 0:
+		// An empty clause can never be satisfied
+		if p.conflict < 0 {
+			p.conflict = clauseID
+		}
+	case 1:
+		// Unit clauses have nothing to watch, they are assigned right away
+		switch p.Value(lits[0]) {
+		case 0:
+			p.assign(lits[0], clauseID)
+		case -1:
+			if p.conflict < 0 {
+				p.conflict = clauseID
+			}
+		}
+	default:
+		p.watches[lits[0]] = append(p.watches[lits[0]], clauseID)
+		p.watches[lits[1]] = append(p.watches[lits[1]], clauseID)
+	}
+}
+
+// relocate updates the clause indices after the database was compacted. The
+// watched positions of the clauses are unchanged, so the watch lists can be
+// rebuilt from scratch. Clauses that are the reason of an assignment must not
+// have been deleted.
+func (p *propagator) relocate(remap []int) {
+	for id, reason := range p.reasons {
+		if reason >= 0 {
+			p.reasons[id] = remap[reason]
+		}
+	}
+	if p.conflict >= 0 {
+		p.conflict = remap[p.conflict]
+	}
+
+	for lit := range p.watches {
+		p.watches[lit] = p.watches[lit][:0]
+	}
+	for clauseID := 0; clauseID < p.db.Len(); clauseID++ {
+		if lits := p.db.Lits(clauseID); len(lits) >= 2 {
+			p.watches[lits[0]] = append(p.watches[lits[0]], clauseID)
+			p.watches[lits[1]] = append(p.watches[lits[1]], clauseID)
+		}
+	}
+}
+
+// memory estimates the bytes held by the clauses, the watch lists and the
+// per-variable state
+func (p *propagator) memory() int64 {
+	memory := p.db.memory()
+	for _, watchList := range p.watches {
+		memory += sliceBytes + int64(cap(watchList))*intBytes
+	}
+	memory += int64(cap(p.assigns)) + int64(cap(p.levels)+cap(p.reasons))*intBytes
+	memory += int64(cap(p.trail))*litBytes + int64(cap(p.trailLim)+cap(p.qheadLim))*intBytes
+	return memory
+}
+
+// isReason reports if the clause currently implies the assignment of its first literal
+func (p *propagator) isReason(clauseID int) bool {
+	lits := p.db.Lits(clauseID)
+	return len(lits) > 0 && p.Value(lits[0]) == 1 && p.reasons[lits[0].Var()] == clauseID
+}
+
+// attachHeuristic registers the heuristic for backtracking notifications if it needs them
+func (p *propagator) attachHeuristic(heuristic BranchingHeuristic) {
+	if listener, ok := heuristic.(BacktrackListener); ok {
+		p.listener = listener
+	}
+}
+
+// levelCount returns the number of distinct decision levels the variables of
+// the given literals were assigned at
+func (p *propagator) levelCount(lits []Lit) int {
+	levels := make(map[int]bool, len(lits))
+	for _, lit := range lits {
+		levels[p.levels[lit.Var()]] = true
+	}
+	return len(levels)
+}
+
+func (p *propagator) decisionLevel() int {
+	return len(p.trailLim)
+}
+
+func (p *propagator) newDecisionLevel() {
+	p.trailLim = append(p.trailLim, len(p.trail))
+	p.qheadLim = append(p.qheadLim, p.qhead)
+}
+
+// Value returns 1 if the literal is true, -1 if it is false and 0 if it is unassigned
+func (p *propagator) Value(lit Lit) int8 {
+	if lit.Negated() {
+		return -p.assigns[lit.Var()]
+	}
+	return p.assigns[lit.Var()]
+}
+
+func (p *propagator) NumVars() int {
+	return p.db.NumVars
+}
+
+func (p *propagator) NumClauses() int {
+	return p.db.Len()
+}
+
+func (p *propagator) ClauseLits(clauseID int) []Lit {
+	return p.db.Lits(clauseID)
+}
+
+func (p *propagator) IsLearned(clauseID int) bool {
+	return p.db.IsLearned(clauseID)
+}
+
+func (p *propagator) assign(lit Lit, reason int) {
+	if lit.Negated() {
+		p.assigns[lit.Var()] = -1
+	} else {
+		p.assigns[lit.Var()] = 1
+	}
+	p.levels[lit.Var()] = p.decisionLevel()
+	p.reasons[lit.Var()] = reason
+	p.trail = append(p.trail, lit)
+}
+
+// cancelUntil undoes every assignment made above the given decision level.
+// The watches stay valid, unassigning variables never breaks the invariant.
+// Assignments of the kept levels that were only propagated after a higher
+// level was opened, e.g. clauses added on level 0 between two searches, are
+// propagated again, their implications were undone with that level.
+func (p *propagator) cancelUntil(level int) {
+	if p.decisionLevel() <= level {
+		return
+	}
+	for i := len(p.trail) - 1; i >= p.trailLim[level]; i-- {
+		id := p.trail[i].Var()
+		p.assigns[id] = 0
+		p.reasons[id] = -1
+		if p.listener != nil {
+			p.listener.Unassigned(p.trail[i])
+		}
+	}
+	p.trail = p.trail[:p.trailLim[level]]
+	p.qhead = min(p.qhead, p.qheadLim[level])
+	p.trailLim = p.trailLim[:level]
+	p.qheadLim = p.qheadLim[:level]
+}
+
+// propagate assigns every literal implied through the watched clauses until a
+// fixpoint is reached. It returns the index of a falsified clause, or -1 if
+// there is none.
+func (p *propagator) propagate() int {
+	if p.conflict >= 0 {
+		return p.conflict
+	}
+
+	for p.qhead < len(p.trail) {
+		falseLit := p.trail[p.qhead].Not()
+		p.qhead++
+
+		watchList := p.watches[falseLit]
+		kept := watchList[:0]
+		conflict := -1
+
+		for i, clauseID := range watchList {
+			if conflict >= 0 {
+				// Keep the remaining watches untouched once a conflict is found
+				kept = append(kept, watchList[i:]...)
+				break
+			}
+
+			lits := p.db.Lits(clauseID)
+
+			// Make sure the falsified watch is at position 1
+			if lits[0] == falseLit {
+				lits[0], lits[1] = lits[1], lits[0]
+			}
+
+			// Clause is already satisfied through the other watch
+			if p.Value(lits[0]) == 1 {
+				kept = append(kept, clauseID)
+				continue
+			}
+
+			// Look for a non-false literal to watch instead
+			moved := false
+			for k := 2; k < len(lits); k++ {
+				if p.Value(lits[k]) != -1 {
+					lits[1], lits[k] = lits[k], lits[1]
+					p.watches[lits[1]] = append(p.watches[lits[1]], clauseID)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+
+			// No replacement found, the clause is either unit or falsified
+			kept = append(kept, clauseID)
+			if p.Value(lits[0]) == -1 {
+				conflict = clauseID
+			} else {
+				p.assign(lits[0], clauseID)
+				p.propagations++
+			}
+		}
+
+		p.watches[falseLit] = kept
+		if conflict >= 0 {
+			if p.decisionLevel() == 0 {
+				// Nothing can be undone, the clauses are unsatisfiable
+				p.conflict = conflict
+			}
+			p.qhead = len(p.trail)
+			return conflict
+		}
+	}
+
+	return -1
+}
+
+func (p *propagator) isSatisfied(clauseID int) bool {
+	for _, lit := range p.db.Lits(clauseID) {
+		if p.Value(lit) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// failedAssumptions follows the reasons of the given false literals back to
+// the assignments without a reason and returns them. If one of those is not
+// among the assumptions, e.g. a decision of the search, all assumptions are
+// returned instead.
+func (p *propagator) failedAssumptions(falsified []Lit, assumptions []Lit) []Lit {
+	isAssumption := make([]bool, len(p.watches))
+	for _, lit := range assumptions {
+		isAssumption[lit] = true
+	}
+	seen := make([]bool, len(p.assigns))
+	for _, lit := range falsified {
+		seen[lit.Var()] = true
+	}
+
+	failed := make([]Lit, 0)
+	for i := len(p.trail) - 1; i >= 0; i-- {
+		lit := p.trail[i]
+		if !seen[lit.Var()] || p.levels[lit.Var()] == 0 {
+			continue
+		}
+		if reason := p.reasons[lit.Var()]; reason >= 0 {
+			for _, other := range p.db.Lits(reason) {
+				seen[other.Var()] = true
+			}
+			continue
+		}
+		if !isAssumption[lit] {
+			return append([]Lit{}, assumptions...)
+		}
+		failed = append(failed, lit)
+	}
+	return failed
+}
+
+// openClauses converts the problem clauses which are not satisfied by the
+// current assignment back to parsed clauses, with falsified variables marked
+// as impossible
+func (p *propagator) openClauses() []*parser.Clause {
+	open := make([]*parser.Clause, 0)
+	for clauseID := 0; clauseID < p.db.Len(); clauseID++ {
+		if p.db.IsLearned(clauseID) || p.isSatisfied(clauseID) {
+			continue
+		}
+		clause := p.db.Clause(clauseID)
+		for i, cVar := range clause.Vars {
+			clause.Vars[i].Impossible = p.Value(LitFromVariable(cVar)) == -1
+		}
+		open = append(open, clause)
+	}
+	return open
+}
@@ -0,0 +1,179 @@
+package solver
+
+import (
+	"testing"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+// taskFromLits builds a task from clauses given as literals
+func taskFromLits(numVars int, clauses [][]Lit) *parser.Task {
+	task := &parser.Task{NumVars: numVars}
+	for i, lits := range clauses {
+		clause := ClauseFromLits(lits)
+		clause.Line = i + 1
+		task.Clauses = append(task.Clauses, clause)
+	}
+	return task
+}
+
+// pos and neg shorten the literals of the hand-written formulas
+func pos(v int) Lit { return MkLit(v, false) }
+func neg(v int) Lit { return MkLit(v, true) }
+
+func TestPropagatorImplicationChain(t *testing.T) {
+	// 1 -> 2 -> 3 -> 4
+	task := taskFromLits(4, [][]Lit{{neg(1), pos(2)}, {neg(2), pos(3)}, {neg(3), pos(4)}})
+	p := newPropagator(NewClauseDB(task))
+
+	p.newDecisionLevel()
+	p.assign(pos(1), -1)
+	if conflict := p.propagate(); conflict >= 0 {
+		t.Fatalf("unexpected conflict in clause %d", conflict)
+	}
+
+	for v := 1; v <= 4; v++ {
+		if p.Value(pos(v)) != 1 {
+			t.Errorf("variable %d not implied true", v)
+		}
+		if p.levels[v] != 1 {
+			t.Errorf("variable %d assigned on level %d, want 1", v, p.levels[v])
+		}
+	}
+	if p.reasons[1] != -1 {
+		t.Errorf("decision has reason %d", p.reasons[1])
+	}
+	for v := 2; v <= 4; v++ {
+		if reason := p.reasons[v]; reason != v-2 {
+			t.Errorf("variable %d implied by clause %d, want %d", v, reason, v-2)
+		}
+	}
+	if p.propagations != 3 {
+		t.Errorf("%d propagations, want 3", p.propagations)
+	}
+}
+
+func TestPropagatorConflict(t *testing.T) {
+	task := taskFromLits(3, [][]Lit{{neg(1), pos(2)}, {neg(1), pos(3)}, {neg(2), neg(3)}})
+	p := newPropagator(NewClauseDB(task))
+
+	if conflict := p.propagate(); conflict >= 0 {
+		t.Fatalf("conflict %d before any decision", conflict)
+	}
+	p.newDecisionLevel()
+	p.assign(pos(1), -1)
+	if conflict := p.propagate(); conflict < 0 {
+		t.Fatal("falsified clause not reported")
+	} else {
+		for _, lit := range p.db.Lits(conflict) {
+			if p.Value(lit) != -1 {
+				t.Errorf("clause %d reported as conflict has literal %s not false", conflict, lit)
+			}
+		}
+	}
+	if p.conflict >= 0 {
+		t.Error("conflict above level 0 stored as permanent")
+	}
+}
+
+func TestPropagatorConflictOnLevelZero(t *testing.T) {
+	task := taskFromLits(2, [][]Lit{{pos(1)}, {neg(1), pos(2)}, {neg(2)}})
+	p := newPropagator(NewClauseDB(task))
+
+	if conflict := p.propagate(); conflict < 0 {
+		t.Fatal("contradicting unit clauses not reported")
+	}
+	if p.propagate() < 0 {
+		t.Error("conflict on level 0 not reported again")
+	}
+}
+
+func TestPropagatorCancelUntil(t *testing.T) {
+	task := taskFromLits(4, [][]Lit{{pos(1)}, {neg(1), pos(2)}, {neg(3), pos(4)}})
+	p := newPropagator(NewClauseDB(task))
+	p.propagate()
+
+	p.newDecisionLevel()
+	p.assign(pos(3), -1)
+	p.propagate()
+	if p.Value(pos(4)) != 1 {
+		t.Fatal("variable 4 not implied")
+	}
+
+	p.cancelUntil(0)
+	if p.decisionLevel() != 0 {
+		t.Errorf("decision level %d after cancelling to 0", p.decisionLevel())
+	}
+	for _, v := range []int{3, 4} {
+		if p.Value(pos(v)) != 0 || p.reasons[v] != -1 {
+			t.Errorf("variable %d still assigned", v)
+		}
+	}
+	for _, v := range []int{1, 2} {
+		if p.Value(pos(v)) != 1 {
+			t.Errorf("level 0 assignment of variable %d undone", v)
+		}
+	}
+	if len(p.trail) != 2 {
+		t.Errorf("trail %v, want the two level 0 assignments", p.trail)
+	}
+
+	// The watches are still valid, the same decision implies the same again
+	p.newDecisionLevel()
+	p.assign(pos(3), -1)
+	p.propagate()
+	if p.Value(pos(4)) != 1 {
+		t.Error("variable 4 not implied after backtracking")
+	}
+}
+
+func TestPropagatorAddClause(t *testing.T) {
+	task := taskFromLits(3, [][]Lit{{neg(1), pos(2)}})
+	p := newPropagator(NewClauseDB(task))
+
+	// A clause with all but one literal false is propagated right away
+	p.addClause([]Lit{neg(3)})
+	p.addClause([]Lit{pos(3), pos(1)})
+	if p.propagate() >= 0 {
+		t.Fatal("unexpected conflict")
+	}
+	if p.Value(pos(1)) != 1 || p.Value(pos(2)) != 1 {
+		t.Errorf("added clauses not propagated: trail %v", p.trail)
+	}
+
+	// A falsified clause stays a conflict
+	p.addClause([]Lit{neg(2), pos(3)})
+	if p.propagate() < 0 {
+		t.Error("falsified clause not reported")
+	}
+}
+
+func TestPropagatorRelocateKeepsReasons(t *testing.T) {
+	task := taskFromLits(6, [][]Lit{{neg(1), pos(2)}, {pos(5), pos(6)}, {neg(2), pos(3)}, {neg(3), pos(4)}, {pos(5), neg(6)}})
+	p := newPropagator(NewClauseDB(task))
+	p.newDecisionLevel()
+	p.assign(pos(1), -1)
+	p.propagate()
+
+	// Only clauses that imply nothing may be deleted
+	for clauseID, want := range []bool{true, false, true, true, false} {
+		if p.isReason(clauseID) != want {
+			t.Errorf("clause %d is a reason: %v", clauseID, !want)
+		}
+	}
+	p.db.Delete(1)
+	p.relocate(p.db.Compact())
+
+	for v := 2; v <= 4; v++ {
+		if reason := p.reasons[v]; reason < 0 || p.db.Lits(reason)[0] != pos(v) {
+			t.Errorf("variable %d has reason %d after relocating", v, reason)
+		}
+	}
+
+	// The watches follow the moved clauses
+	p.newDecisionLevel()
+	p.assign(neg(5), -1)
+	if conflict := p.propagate(); conflict >= 0 || p.Value(neg(6)) != 1 || p.reasons[6] != 3 {
+		t.Errorf("-6 not implied by the moved clause 3: conflict %d, reason %d", conflict, p.reasons[6])
+	}
+}
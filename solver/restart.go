@@ -0,0 +1,148 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+)
+
+type RestartKind int
+
+const (
+	NoRestarts        RestartKind = iota // Never restart
+	FixedRestarts                        // Restart every RestartInterval conflicts
+	LubyRestarts                         // Restart after RestartInterval times the next Luby number of conflicts
+	GeometricRestarts                    // Restart after RestartInterval conflicts, growing by RestartFactor each time
+	GlucoseRestarts                      // Restart when recent learned clauses have a worse LBD than the average
+)
+
+func (r RestartKind) String() string {
+	return [...]string{"none", "fixed", "luby", "geometric", "glucose"}[r]
+}
+
+// ParseRestart converts a string to a RestartKind
+func ParseRestart(restartStr string) (RestartKind, error) {
+	for kind := NoRestarts; kind <= GlucoseRestarts; kind++ {
+		if kind.String() == restartStr {
+			return kind, nil
+		}
+	}
+	return NoRestarts, fmt.Errorf("unknown restart policy '%s', expected one of 'none', 'fixed', 'luby', 'geometric' or 'glucose'", restartStr)
+}
+
+// RestartPolicy decides when the search abandons its current decisions and
+// starts over from decision level 0. Learned clauses and saved phases are
+// kept, so the new search usually takes a different and shorter path.
+type RestartPolicy interface {
+	// OnConflict is called after every conflict with the literal block
+	// distance of the learned clause and reports if the solver should restart
+	OnConflict(lbd int) bool
+}
+
+// NewRestartPolicy creates the restart policy configured in the options
+func NewRestartPolicy(options Options) RestartPolicy {
+	interval := max(options.RestartInterval, 1)
+
+	switch options.Restart {
+	case FixedRestarts:
+		return &intervalRestarts{next: func(int) float64 { return float64(interval) }}
+	case LubyRestarts:
+		return &intervalRestarts{next: func(restarts int) float64 { return float64(interval) * luby(2, restarts) }}
+	case GeometricRestarts:
+		return &intervalRestarts{next: func(restarts int) float64 {
+			return float64(interval) * math.Pow(options.RestartFactor, float64(restarts))
+		}}
+	case GlucoseRestarts:
+		return &glucoseRestarts{window: interval}
+	default:
+		return &noRestarts{}
+	}
+}
+
+type noRestarts struct{}
+
+func (r *noRestarts) OnConflict(lbd int) bool {
+	return false
+}
+
+// intervalRestarts restarts after a number of conflicts which depends on the
+// number of restarts done so far
+type intervalRestarts struct {
+	next      func(restarts int) float64
+	conflicts int
+	restarts  int
+}
+
+func (r *intervalRestarts) OnConflict(lbd int) bool {
+	r.conflicts++
+	if float64(r.conflicts) < r.next(r.restarts) {
+		return false
+	}
+	r.conflicts = 0
+	r.restarts++
+	return true
+}
+
+// luby returns the i-th element (starting at 0) of the Luby sequence with the
+// given base, e.g. 1 1 2 1 1 2 4 1 1 2 ... for base 2
+func luby(base float64, i int) float64 {
+	// Find the finite subsequence that contains index i, and its size
+	size, seq := 1, 0
+	for size < i+1 {
+		seq++
+		size = 2*size + 1
+	}
+
+	for size-1 != i {
+		size = (size - 1) >> 1
+		seq--
+		i = i % size
+	}
+
+	return math.Pow(base, float64(seq))
+}
+
+// glucoseRestarts compares the LBD average of the last window learned clauses
+// with the average of all learned clauses. If the recent clauses are clearly
+// worse the current search prefix is unlikely to lead anywhere.
+type glucoseRestarts struct {
+	window int
+	recent []int // Ring buffer with the LBDs of the last window clauses
+	next   int   // Position in the ring buffer to write to
+	sum    int   // Sum of the LBDs in the ring buffer
+
+	totalLBD  float64
+	conflicts int
+}
+
+// glucoseMargin is the factor by which the recent average has to exceed the global one
+const glucoseMargin = 0.8
+
+func (r *glucoseRestarts) OnConflict(lbd int) bool {
+	r.conflicts++
+	r.totalLBD += float64(lbd)
+
+	if len(r.recent) < r.window {
+		r.recent = append(r.recent, lbd)
+	} else {
+		r.sum -= r.recent[r.next]
+		r.recent[r.next] = lbd
+		r.next = (r.next + 1) % r.window
+	}
+	r.sum += lbd
+
+	// Wait until the window is filled again after every restart
+	if len(r.recent) < r.window {
+		return false
+	}
+
+	recentAverage := float64(r.sum) / float64(r.window)
+	totalAverage := r.totalLBD / float64(r.conflicts)
+	if recentAverage*glucoseMargin <= totalAverage {
+		return false
+	}
+
+	r.recent = r.recent[:0]
+	r.next = 0
+	r.sum = 0
+	return true
+}
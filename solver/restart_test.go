@@ -0,0 +1,84 @@
+package solver
+
+import "testing"
+
+func TestLuby(t *testing.T) {
+	want := []float64{1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8, 1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8, 16, 1}
+	for i, value := range want {
+		if got := luby(2, i); got != value {
+			t.Errorf("luby(2, %d) = %v, want %v", i, got, value)
+		}
+	}
+	if got := luby(3, 6); got != 9 {
+		t.Errorf("luby(3, 6) = %v, want 9", got)
+	}
+}
+
+// restartsAt returns the conflicts after which a policy asks for a restart
+func restartsAt(policy RestartPolicy, conflicts int, lbd func(conflict int) int) []int {
+	restarts := make([]int, 0)
+	for conflict := 1; conflict <= conflicts; conflict++ {
+		if policy.OnConflict(lbd(conflict)) {
+			restarts = append(restarts, conflict)
+		}
+	}
+	return restarts
+}
+
+func TestRestartPolicies(t *testing.T) {
+	constant := func(int) int { return 3 }
+	tests := []struct {
+		restart RestartKind
+		want    []int
+	}{
+		{NoRestarts, []int{}},
+		{FixedRestarts, []int{10, 20, 30, 40, 50, 60}},
+		// Intervals 10 10 20 10 10
+		{LubyRestarts, []int{10, 20, 40, 50, 60}},
+		// Intervals 10 15 22.5 -> 23
+		{GeometricRestarts, []int{10, 25, 48}},
+		// The LBDs never get worse than the average
+		{GlucoseRestarts, []int{}},
+	}
+	for _, tt := range tests {
+		options := DefaultOptions()
+		options.Restart = tt.restart
+		options.RestartInterval = 10
+		options.RestartFactor = 1.5
+
+		got := restartsAt(NewRestartPolicy(options), 60, constant)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: restarts after %v conflicts, want %v", tt.restart, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: restarts after %v conflicts, want %v", tt.restart, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestGlucoseRestartsOnWorseLBD(t *testing.T) {
+	options := DefaultOptions()
+	options.Restart = GlucoseRestarts
+	options.RestartInterval = 5
+
+	// 20 good clauses, then the LBD jumps far above the average
+	got := restartsAt(NewRestartPolicy(options), 30, func(conflict int) int {
+		if conflict <= 20 {
+			return 2
+		}
+		return 20
+	})
+	if len(got) == 0 || got[0] <= 20 {
+		t.Fatalf("restarts after %v conflicts, want the first one after conflict 20", got)
+	}
+	// The window is refilled before the next restart
+	for i := 1; i < len(got); i++ {
+		if got[i]-got[i-1] < options.RestartInterval {
+			t.Errorf("restarts after %v conflicts are closer than the window", got)
+		}
+	}
+}
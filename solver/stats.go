@@ -0,0 +1,74 @@
+package solver
+
+import "time"
+
+// Stats counts the work of the last call of Solve
+type Stats struct {
+	Decisions      int           `json:"decisions"`           // Variables split on
+	Propagations   int           `json:"propagations"`        // Literals assigned by unit propagation
+	PureLiterals   int           `json:"pureLiterals"`        // Literals assigned because their negation occurs in no open clause
+	FailedLiterals int           `json:"failedLiterals"`      // Literals assigned because a lookahead on their negation failed
+	Backtracks     int           `json:"backtracks"`          // Returns to a checkpoint (DPLL) or backjumps (CDCL)
+	Conflicts      int           `json:"conflicts"`           // Clauses falsified by the assignment
+	MaxDepth       int           `json:"maxDepth"`            // Most splits open at once, decision levels for CDCL
+	Depth          int           `json:"depth"`               // Splits open when the search ended, the deepest worker's for the parallel solver
+	Workers        int           `json:"workers"`             // Threads that searched
+	WorkItems      []WorkerStats `json:"workItems,omitempty"` // Per worker of the parallel solver: work items handled
+	Time           PhaseTimes    `json:"time"`                // Time spent per part of the search
+}
+
+// WorkerStats counts the work items of one worker of the parallel solver
+type WorkerStats struct {
+	Processed int `json:"processed"` // Work items taken from the queue
+	Stolen    int `json:"stolen"`    // Of those, work items stolen from another worker
+	Created   int `json:"created"`   // Work items pushed to the queue by parallel splits
+	Shared    int `json:"shared"`    // Learned clauses passed on to the other workers
+	Imported  int `json:"imported"`  // Learned clauses of other workers added before a work item
+}
+
+// PhaseTimes is the time spent in each part of the search. For the parallel
+// solver it is summed over the workers.
+type PhaseTimes struct {
+	Propagation  time.Duration `json:"propagationNs"`  // Unit propagation
+	SolvedChecks time.Duration `json:"solvedChecksNs"` // DPLL: scanning for clauses left open
+	PureLiterals time.Duration `json:"pureLiteralsNs"` // DPLL: finding and assigning pure literals
+	Decisions    time.Duration `json:"decisionsNs"`    // Picking split variables
+	Backtracking time.Duration `json:"backtrackingNs"` // Undoing assignments
+	Analysis     time.Duration `json:"analysisNs"`     // CDCL: conflict analysis, learning and clause database reduction
+}
+
+// Merge adds the counters of a search that ran next to or after this one
+func (st *Stats) Merge(other Stats) {
+	st.Decisions += other.Decisions
+	st.Propagations += other.Propagations
+	st.PureLiterals += other.PureLiterals
+	st.FailedLiterals += other.FailedLiterals
+	st.Backtracks += other.Backtracks
+	st.Conflicts += other.Conflicts
+	st.MaxDepth = max(st.MaxDepth, other.MaxDepth)
+	st.Depth = max(st.Depth, other.Depth)
+	st.Workers = max(st.Workers, other.Workers)
+	for id, items := range other.WorkItems {
+		if id == len(st.WorkItems) {
+			st.WorkItems = append(st.WorkItems, WorkerStats{})
+		}
+		st.WorkItems[id].Processed += items.Processed
+		st.WorkItems[id].Stolen += items.Stolen
+		st.WorkItems[id].Created += items.Created
+		st.WorkItems[id].Shared += items.Shared
+		st.WorkItems[id].Imported += items.Imported
+	}
+
+	st.Time.Propagation += other.Time.Propagation
+	st.Time.SolvedChecks += other.Time.SolvedChecks
+	st.Time.PureLiterals += other.Time.PureLiterals
+	st.Time.Decisions += other.Time.Decisions
+	st.Time.Backtracking += other.Time.Backtracking
+	st.Time.Analysis += other.Time.Analysis
+}
+
+// addTime adds the time since start to a phase, e.g. deferred at the start
+// of the phase
+func addTime(phase *time.Duration, start time.Time) {
+	*phase += time.Since(start)
+}
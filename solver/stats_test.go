@@ -0,0 +1,49 @@
+package solver
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStatsMerge(t *testing.T) {
+	st := Stats{Decisions: 3, Propagations: 10, Conflicts: 1, MaxDepth: 4, Workers: 2}
+	st.Time.Propagation = time.Second
+	other := Stats{Decisions: 2, Propagations: 5, PureLiterals: 1, Backtracks: 7, Conflicts: 2, MaxDepth: 3}
+	other.Time.Propagation = time.Second
+	other.Time.Analysis = time.Millisecond
+
+	st.Merge(other)
+	want := Stats{Decisions: 5, Propagations: 15, PureLiterals: 1, Backtracks: 7, Conflicts: 3, MaxDepth: 4, Workers: 2}
+	want.Time.Propagation = 2 * time.Second
+	want.Time.Analysis = time.Millisecond
+	if st.Decisions != want.Decisions || st.Propagations != want.Propagations || st.PureLiterals != want.PureLiterals ||
+		st.Backtracks != want.Backtracks || st.Conflicts != want.Conflicts || st.MaxDepth != want.MaxDepth ||
+		st.Workers != want.Workers || st.Time != want.Time {
+		t.Errorf("merged stats %+v, want %+v", st, want)
+	}
+}
+
+func TestSolversCountTheirWork(t *testing.T) {
+	task := loadTask(t, exampleFiles(t, "uuf50-218")[0])
+
+	dpll := NewSolver(task, DefaultOptions())
+	dpll.Solve(context.Background())
+	cdcl := NewCDCLSolver(task, DefaultOptions())
+	cdcl.Solve(context.Background())
+	for name, st := range map[string]Stats{"dpll": dpll.Stats, "cdcl": cdcl.Stats} {
+		if st.Decisions == 0 || st.Propagations == 0 || st.Conflicts == 0 || st.Backtracks == 0 {
+			t.Errorf("%s: counters missing in %+v", name, st)
+		}
+		if st.MaxDepth == 0 || st.MaxDepth > task.NumVars || st.Workers != 1 {
+			t.Errorf("%s: depth %d with %d workers", name, st.MaxDepth, st.Workers)
+		}
+	}
+
+	// Every call counts on its own
+	decisions := cdcl.Stats.Decisions
+	cdcl.Solve(context.Background())
+	if cdcl.Stats.Decisions > decisions {
+		t.Errorf("second call counted %d decisions, more than the %d of the first", cdcl.Stats.Decisions, decisions)
+	}
+}
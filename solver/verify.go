@@ -0,0 +1,70 @@
+package solver
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+type ClauseStatus int
+
+const (
+	ClauseSatisfied ClauseStatus = iota // The model contains one of its literals
+	ClauseFalsified                     // The model contains the negation of all its literals
+	ClauseUndecided                     // Neither, some of its variables are unassigned
+)
+
+func (s ClauseStatus) String() string {
+	return [...]string{"SATISFIED", "FALSIFIED", "UNDECIDED"}[s]
+}
+
+// Verify checks that a model satisfies every clause of a task, independent of
+// how the solver found it. The model may leave variables unassigned, but a
+// clause is only satisfied by a literal the model contains. It returns an
+// error naming the first clause that is not satisfied, or the variables the
+// model assigns both true and false.
+func Verify(task *parser.Task, model *parser.Clause) error {
+	statuses, conflicting := CheckModel(task, model)
+	if len(conflicting) > 0 {
+		return fmt.Errorf("model assigns variables %v both true and false", conflicting)
+	}
+
+	for i, status := range statuses {
+		if status != ClauseSatisfied {
+			clause := task.Clauses[i]
+			return fmt.Errorf("clause %d (line %d) %s is %s", i, clause.Line, clause, status)
+		}
+	}
+	return nil
+}
+
+// CheckModel returns the status of every clause of a task under a model, and
+// the variables the model assigns both true and false in the order they are
+// found. Of conflicting assignments the last one counts.
+func CheckModel(task *parser.Task, model *parser.Clause) ([]ClauseStatus, []int) {
+	values := make(map[int]bool)
+	conflicting := make([]int, 0)
+	for _, v := range model.Vars {
+		value, assigned := values[v.ID]
+		if assigned && value == v.Negated && !slices.Contains(conflicting, v.ID) {
+			conflicting = append(conflicting, v.ID)
+		}
+		values[v.ID] = !v.Negated
+	}
+
+	statuses := make([]ClauseStatus, len(task.Clauses))
+	for i, clause := range task.Clauses {
+		statuses[i] = ClauseFalsified
+		for _, v := range clause.Vars {
+			value, assigned := values[v.ID]
+			if !assigned {
+				statuses[i] = ClauseUndecided
+			} else if value != v.Negated {
+				statuses[i] = ClauseSatisfied
+				break
+			}
+		}
+	}
+	return statuses, conflicting
+}
@@ -0,0 +1,91 @@
+package solver
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/CptPie/DLPP-solver/parser"
+)
+
+func TestCheckModel(t *testing.T) {
+	task := taskFromLits(4, [][]Lit{{pos(1), pos(2)}, {neg(1), pos(3)}, {neg(2), pos(4)}})
+
+	tests := []struct {
+		name        string
+		model       []Lit
+		statuses    []ClauseStatus
+		conflicting []int
+	}{
+		{"complete", []Lit{pos(1), neg(2), pos(3), neg(4)}, []ClauseStatus{ClauseSatisfied, ClauseSatisfied, ClauseSatisfied}, []int{}},
+		{"falsified", []Lit{pos(1), neg(2), neg(3), neg(4)}, []ClauseStatus{ClauseSatisfied, ClauseFalsified, ClauseSatisfied}, []int{}},
+		{"partial", []Lit{pos(1), neg(2)}, []ClauseStatus{ClauseSatisfied, ClauseUndecided, ClauseSatisfied}, []int{}},
+		{"empty", []Lit{}, []ClauseStatus{ClauseUndecided, ClauseUndecided, ClauseUndecided}, []int{}},
+		// Of conflicting assignments the last one counts
+		{"conflicting", []Lit{pos(1), neg(2), neg(1), pos(2), neg(2)}, []ClauseStatus{ClauseFalsified, ClauseSatisfied, ClauseSatisfied}, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses, conflicting := CheckModel(task, ClauseFromLits(tt.model))
+			if !slices.Equal(statuses, tt.statuses) {
+				t.Errorf("statuses %v, want %v", statuses, tt.statuses)
+			}
+			if !slices.Equal(conflicting, tt.conflicting) {
+				t.Errorf("conflicting variables %v, want %v", conflicting, tt.conflicting)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	task := taskFromLits(4, [][]Lit{{pos(1), pos(2)}, {neg(1), pos(3)}, {neg(2), pos(4)}})
+
+	if err := Verify(task, ClauseFromLits([]Lit{pos(1), neg(2), pos(3)})); err != nil {
+		t.Errorf("partial model rejected: %v", err)
+	}
+	err := Verify(task, ClauseFromLits([]Lit{pos(1), pos(2), neg(3), pos(4)}))
+	if err == nil || !strings.Contains(err.Error(), "clause 1 (line 2)") || !strings.Contains(err.Error(), ClauseFalsified.String()) {
+		t.Errorf("falsified clause reported as %v", err)
+	}
+	err = Verify(task, ClauseFromLits([]Lit{pos(1), pos(2), pos(4)}))
+	if err == nil || !strings.Contains(err.Error(), ClauseUndecided.String()) {
+		t.Errorf("undecided clause reported as %v", err)
+	}
+	err = Verify(task, ClauseFromLits([]Lit{pos(1), pos(3), pos(2), pos(4), neg(4)}))
+	if err == nil || !strings.Contains(err.Error(), "both true and false") {
+		t.Errorf("conflicting model reported as %v", err)
+	}
+}
+
+func TestVerifySolutionsOfExamples(t *testing.T) {
+	for _, file := range exampleFiles(t, "uf50-218") {
+		task := loadTask(t, file)
+		s := NewSolver(task, DefaultOptions())
+		s.Solve(context.Background())
+		if s.Result != SATISFIABLE {
+			t.Fatalf("%s: result %s", filepath.Base(file), s.Result)
+		}
+		if err := Verify(task, s.Solution); err != nil {
+			t.Errorf("%s: solution rejected: %v", filepath.Base(file), err)
+		}
+
+		// Falsify the first clause by setting all its variables the other way
+		broken := &parser.Clause{Vars: slices.Clone(s.Solution.Vars)}
+		for _, cVar := range task.Clauses[0].Vars {
+			for i := range broken.Vars {
+				if broken.Vars[i].ID == cVar.ID {
+					broken.Vars[i].Negated = !cVar.Negated
+				}
+			}
+		}
+		statuses, _ := CheckModel(task, broken)
+		if statuses[0] != ClauseFalsified {
+			t.Errorf("%s: first clause is %s under the broken model", filepath.Base(file), statuses[0])
+		}
+		if err := Verify(task, broken); err == nil {
+			t.Errorf("%s: broken model accepted", filepath.Base(file))
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package solver
+
+// vsids keeps an activity score per variable which is increased for every
+// variable involved in a conflict. Older bumps decay by growing the increment
+// instead of shrinking all scores (EVSIDS). Unassigned variables are kept in
+// a heap ordered by activity, so picking a decision does not need to look at
+// the clauses. Every variable remembers the polarity it was last assigned
+// with (phase saving) and is decided with that polarity again.
+type vsids struct {
+	activity  []float64
+	increment float64
+	phase     []bool // Per variable: true if it was last assigned negated
+	heap      *varHeap
+
+	initialized bool
+}
+
+const (
+	vsidsDecay   = 0.95
+	vsidsRescale = 1e100
+)
+
+func newVSIDS(numVars int) *vsids {
+	h := &vsids{
+		activity:  make([]float64, numVars+1),
+		increment: 1,
+		phase:     make([]bool, numVars+1),
+	}
+	h.heap = newVarHeap(h.activity)
+	return h
+}
+
+// initialize seeds the activities with the occurrence counts, so the first
+// decisions before any conflict follow the most constrained variables, and
+// sets the initial phase to the more frequent polarity
+func (h *vsids) initialize(state SearchState) {
+	counts := make([]float64, 2*len(h.activity))
+	for clauseID := 0; clauseID < state.NumClauses(); clauseID++ {
+		for _, lit := range state.ClauseLits(clauseID) {
+			if lit.Var() < len(h.activity) {
+				counts[lit]++
+			}
+		}
+	}
+
+	// Keep the seeded activity below a single bump
+	maxCount := 1.0
+	for _, count := range counts {
+		maxCount = max(maxCount, count)
+	}
+
+	for id := 1; id < len(h.activity); id++ {
+		pos, neg := counts[MkLit(id, false)], counts[MkLit(id, true)]
+		h.activity[id] = (pos + neg) / (2 * maxCount)
+		h.phase[id] = neg > pos
+		h.heap.insert(id)
+	}
+	h.initialized = true
+}
+
+// grow makes room for variables added after the heuristic was created
+func (h *vsids) grow(numVars int) {
+	for id := len(h.activity); id <= numVars; id++ {
+		h.activity = append(h.activity, 0)
+		h.phase = append(h.phase, false)
+		h.heap.indices = append(h.heap.indices, -1)
+		h.heap.activity = h.activity
+		if h.initialized {
+			h.heap.insert(id)
+		}
+	}
+}
+
+func (h *vsids) PickBranch(state SearchState) (Lit, bool) {
+	if state.NumVars() >= len(h.activity) {
+		h.grow(state.NumVars())
+	}
+	if !h.initialized {
+		h.initialize(state)
+	}
+
+	// Assigned variables stay in the heap until they come up, skip them
+	for !h.heap.empty() {
+		id := h.heap.pop()
+		lit := MkLit(id, h.phase[id])
+		if state.Value(lit) == 0 {
+			return lit, true
+		}
+	}
+
+	// Every variable is assigned
+	return 0, false
+}
+
+func (h *vsids) Bump(lits []Lit) {
+	for _, lit := range lits {
+		id := lit.Var()
+		if id >= len(h.activity) {
+			continue
+		}
+		h.activity[id] += h.increment
+		if h.activity[id] > vsidsRescale {
+			// Scale everything down before the scores overflow
+			for i := range h.activity {
+				h.activity[i] /= vsidsRescale
+			}
+			h.increment /= vsidsRescale
+		}
+		h.heap.update(id)
+	}
+	h.increment /= vsidsDecay
+}
+
+// Unassigned saves the phase of the variable and makes it available for decisions again
+func (h *vsids) Unassigned(lit Lit) {
+	id := lit.Var()
+	if id >= len(h.activity) {
+		return
+	}
+	h.phase[id] = lit.Negated()
+	if h.initialized {
+		h.heap.insert(id)
+	}
+}
+
+// varHeap is a binary max-heap of variable IDs ordered by their activity
+type varHeap struct {
+	activity []float64
+	heap     []int
+	indices  []int // Per variable: position in the heap, -1 if it is not contained
+}
+
+func newVarHeap(activity []float64) *varHeap {
+	indices := make([]int, len(activity))
+	for i := range indices {
+		indices[i] = -1
+	}
+	return &varHeap{
+		activity: activity,
+		indices:  indices,
+	}
+}
+
+func (vh *varHeap) empty() bool {
+	return len(vh.heap) == 0
+}
+
+func (vh *varHeap) contains(id int) bool {
+	return vh.indices[id] >= 0
+}
+
+func (vh *varHeap) insert(id int) {
+	if vh.contains(id) {
+		return
+	}
+	vh.indices[id] = len(vh.heap)
+	vh.heap = append(vh.heap, id)
+	vh.up(len(vh.heap) - 1)
+}
+
+// update restores the heap order after the activity of a variable increased
+func (vh *varHeap) update(id int) {
+	if vh.contains(id) {
+		vh.up(vh.indices[id])
+	}
+}
+
+func (vh *varHeap) pop() int {
+	top := vh.heap[0]
+	last := len(vh.heap) - 1
+	vh.swap(0, last)
+	vh.heap = vh.heap[:last]
+	vh.indices[top] = -1
+	if last > 0 {
+		vh.down(0)
+	}
+	return top
+}
+
+// less orders by activity, ties prefer the lower variable ID
+func (vh *varHeap) less(i, j int) bool {
+	a, b := vh.heap[i], vh.heap[j]
+	if vh.activity[a] != vh.activity[b] {
+		return vh.activity[a] > vh.activity[b]
+	}
+	return a < b
+}
+
+func (vh *varHeap) swap(i, j int) {
+	vh.heap[i], vh.heap[j] = vh.heap[j], vh.heap[i]
+	vh.indices[vh.heap[i]] = i
+	vh.indices[vh.heap[j]] = j
+}
+
+func (vh *varHeap) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !vh.less(i, parent) {
+			break
+		}
+		vh.swap(i, parent)
+		i = parent
+	}
+}
+
+func (vh *varHeap) down(i int) {
+	for {
+		child := 2*i + 1
+		if child >= len(vh.heap) {
+			break
+		}
+		if right := child + 1; right < len(vh.heap) && vh.less(right, child) {
+			child = right
+		}
+		if !vh.less(child, i) {
+			break
+		}
+		vh.swap(i, child)
+		i = child
+	}
+}
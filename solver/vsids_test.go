@@ -0,0 +1,132 @@
+package solver
+
+import (
+	"slices"
+	"testing"
+)
+
+// popAll empties the heap and returns the variables in the order they came
+func popAll(vh *varHeap) []int {
+	ids := make([]int, 0)
+	for !vh.empty() {
+		ids = append(ids, vh.pop())
+	}
+	return ids
+}
+
+func TestVarHeapOrder(t *testing.T) {
+	activity := []float64{0, 3, 7, 3, 1, 7, 0.5}
+	vh := newVarHeap(activity)
+	for _, id := range []int{4, 1, 6, 3, 2, 5} {
+		vh.insert(id)
+	}
+	vh.insert(2)
+
+	// Highest activity first, ties go to the lower variable
+	want := []int{2, 5, 1, 3, 4, 6}
+	if got := popAll(vh); !slices.Equal(got, want) {
+		t.Errorf("popped %v, want %v", got, want)
+	}
+
+	// A raised activity moves a variable up
+	for id := 1; id <= 6; id++ {
+		vh.insert(id)
+	}
+	activity[6] = 10
+	vh.update(6)
+	activity[4] = 5
+	vh.update(4)
+	want = []int{6, 2, 5, 4, 1, 3}
+	if got := popAll(vh); !slices.Equal(got, want) {
+		t.Errorf("popped %v after raising 6 and 4, want %v", got, want)
+	}
+}
+
+// picks returns the next decisions of a heuristic without assigning them
+func picks(h BranchingHeuristic, state SearchState, count int) []int {
+	ids := make([]int, 0)
+	for range count {
+		lit, ok := h.PickBranch(state)
+		if !ok {
+			break
+		}
+		ids = append(ids, lit.Var())
+	}
+	return ids
+}
+
+func TestVSIDSBumpAndDecay(t *testing.T) {
+	engine := heuristicTask()
+	h := newVSIDS(engine.NumVars())
+	engine.attachHeuristic(h)
+
+	// Before any conflict the occurrence counts decide: variable 4 occurs 7
+	// times, 2, 5 and 1 occur 5, 5 and 4 times
+	if got, want := picks(h, engine, 4), []int{4, 2, 5, 1}; !slices.Equal(got, want) {
+		t.Errorf("first picks %v, want %v", got, want)
+	}
+	for id := 1; id <= 6; id++ {
+		h.Unassigned(MkLit(id, false))
+	}
+
+	// A bump outweighs the occurrences, and later bumps count more than
+	// earlier ones
+	h.Bump([]Lit{pos(3)})
+	h.Bump([]Lit{neg(6)})
+	if h.activity[6] <= h.activity[3] {
+		t.Errorf("activity %v of the later bump not above %v", h.activity[6], h.activity[3])
+	}
+	if got, want := picks(h, engine, 3), []int{6, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("picks %v after bumping 3 and 6, want %v", got, want)
+	}
+}
+
+func TestVSIDSRescale(t *testing.T) {
+	engine := heuristicTask()
+	h := newVSIDS(engine.NumVars())
+	picks(h, engine, 1)
+	for id := 1; id <= 6; id++ {
+		h.Unassigned(MkLit(id, false))
+	}
+
+	// Bumping beyond the limit scales every activity down in the same ratio
+	h.Bump([]Lit{pos(1)})
+	h.increment = 2 * vsidsRescale
+	h.Bump([]Lit{pos(5)})
+	if h.activity[5] > vsidsRescale || h.increment >= vsidsRescale {
+		t.Errorf("activity %v and increment %v not rescaled", h.activity[5], h.increment)
+	}
+	if h.activity[1] == 0 || h.activity[1] >= h.activity[5] {
+		t.Errorf("activities %v and %v lost their order", h.activity[1], h.activity[5])
+	}
+	if got, want := picks(h, engine, 3), []int{5, 1, 4}; !slices.Equal(got, want) {
+		t.Errorf("picks %v after rescaling, want %v", got, want)
+	}
+}
+
+func TestVSIDSPhaseSaving(t *testing.T) {
+	engine := heuristicTask()
+	h := newVSIDS(engine.NumVars())
+	engine.attachHeuristic(h)
+
+	// The initial phase is the more frequent polarity
+	lit, _ := h.PickBranch(engine)
+	if lit != neg(4) {
+		t.Fatalf("first pick %s, want -4", lit)
+	}
+
+	// Variables are decided again with the polarity they had when unassigned
+	engine.newDecisionLevel()
+	engine.assign(pos(4), -1)
+	engine.propagate()
+	implied := engine.trail[1:]
+	engine.cancelUntil(0)
+	if lit, _ := h.PickBranch(engine); lit != pos(4) {
+		t.Errorf("picked %s after 4 was true, want 4", lit)
+	}
+	for _, lit := range implied {
+		if h.phase[lit.Var()] != lit.Negated() {
+			t.Errorf("phase of the implied %s not saved", lit)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/CptPie/DLPP-solver/logger"
+	dimacsParser "github.com/CptPie/DLPP-solver/parser"
+	"github.com/CptPie/DLPP-solver/solver"
+)
+
+// searchState is the part of the search a stopped run left open, written with
+// --dump-state and continued with --resume
+type searchState struct {
+	File    string  `json:"file"`              // Input file the state belongs to
+	Cubes   [][]int `json:"cubes"`             // Parts of the search space left, each as DIMACS literals to assume
+	Clauses [][]int `json:"clauses,omitempty"` // Learned clauses, they follow from the input file
+	Best    []int   `json:"best,omitempty"`    // Smallest solution found in --optimum mode so far
+}
+
+// newSearchState returns the state of a search that has not started: the
+// single empty cube covers the whole search space
+func newSearchState(fileName string) *searchState {
+	return &searchState{File: fileName, Cubes: [][]int{{}}}
+}
+
+func loadState(path string, fileName string) *searchState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		exitWithError("Could not read search state: %v\n", err)
+	}
+	state := &searchState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		exitWithError("Could not parse search state: %v\n", err)
+	}
+	if state.File != fileName {
+		logger.Info("Warning: search state was written for %s, not %s\n", state.File, fileName)
+	}
+	logger.Info("Resuming with %d open cubes and %d learned clauses\n", len(state.Cubes), len(state.Clauses))
+	return state
+}
+
+func writeState(path string, state *searchState) {
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		exitWithError("Could not write search state: %v\n", err)
+	}
+	logger.Info("Search state with %d open cubes written to %s, continue with --resume %s\n", len(state.Cubes), path, path)
+}
+
+// solveCubes searches the cubes of the state one after another with the
+// given function, which returns the result of a cube and, if it stopped
+// early, the cubes it left open. The search ends at the first solution, in
+// optimum mode the smallest solution of all cubes is kept. If a cube is left
+// unfinished, or the context or the budgets end the search between cubes, the
+// result is UNKNOWN and the state keeps the cubes still open.
+func solveCubes(ctx context.Context, state *searchState, stats *solver.Stats, solve func(cube []solver.Lit) (solver.Result, *dimacsParser.Clause, [][]solver.Lit)) (solver.Result, *dimacsParser.Clause) {
+	var best *dimacsParser.Clause
+	if state.Best != nil {
+		best = clauseFromInts(state.Best)
+	}
+
+	var solution *dimacsParser.Clause
+	for len(state.Cubes) > 0 {
+		if ctx.Err() != nil || budgetUsed(stats) {
+			if Args.Optimum {
+				return solver.UNKNOWN, best
+			}
+			return solver.UNKNOWN, solution
+		}
+
+		var result solver.Result
+		var open [][]solver.Lit
+		result, solution, open = solve(litsFromInts(state.Cubes[0]))
+
+		if solution != nil && result != solver.UNSATISFIABLE && Args.Optimum {
+			if best == nil || len(solution.Vars) < len(best.Vars) {
+				best = solution
+				state.Best = intsFromClause(best)
+			}
+		}
+
+		switch result {
+		case solver.SATISFIABLE:
+			if !Args.Optimum {
+				state.Cubes = nil
+				return result, solution
+			}
+			state.Cubes = state.Cubes[1:]
+		case solver.UNSATISFIABLE:
+			state.Cubes = state.Cubes[1:]
+		default:
+			remaining := make([][]int, 0, len(open)+len(state.Cubes)-1)
+			for _, cube := range open {
+				remaining = append(remaining, intsFromLits(cube))
+			}
+			state.Cubes = mergeCubes(append(remaining, state.Cubes[1:]...))
+			if Args.Optimum {
+				return result, best
+			}
+			return result, solution
+		}
+	}
+
+	if best != nil {
+		return solver.SATISFIABLE, best
+	}
+	return solver.UNSATISFIABLE, solution
+}
+
+// mergeCubes replaces every two cubes that differ only in the sign of one
+// literal by the cube without that literal, as long as there are such pairs.
+// A stopped search leaves the cube it was in together with the siblings of
+// its decisions, so the same part of the search space would otherwise be
+// split up further with every resumed run. The cubes are disjoint, so the
+// merged cubes do not subsume any other. The order is kept, a merged cube
+// takes the place of the earlier one of the pair.
+func mergeCubes(cubes [][]int) [][]int {
+	merged := make([][]int, 0, len(cubes))
+	positions := make(map[string]int) // Position in merged of every cube by its key
+
+	var place func(cube []int, position int)
+	place = func(cube []int, position int) {
+		for i, lit := range cube {
+			sibling := append([]int{}, cube...)
+			sibling[i] = -lit
+			other, ok := positions[cubeKey(sibling)]
+			if !ok {
+				continue
+			}
+			delete(positions, cubeKey(sibling))
+			merged[other] = nil
+			if position >= 0 {
+				merged[position] = nil
+			}
+			parent := append(append([]int{}, cube[:i]...), cube[i+1:]...)
+			if position < 0 || other < position {
+				position = other
+			}
+			place(parent, position)
+			return
+		}
+
+		if position < 0 {
+			position = len(merged)
+			merged = append(merged, nil)
+		}
+		merged[position] = cube
+		positions[cubeKey(cube)] = position
+	}
+	for _, cube := range cubes {
+		if _, ok := positions[cubeKey(cube)]; !ok {
+			place(cube, -1)
+		}
+	}
+
+	result := make([][]int, 0, len(positions))
+	for _, cube := range merged {
+		if cube != nil {
+			result = append(result, cube)
+		}
+	}
+	return result
+}
+
+// cubeKey identifies a cube independent of the order of its literals
+func cubeKey(cube []int) string {
+	sorted := append([]int{}, cube...)
+	sort.Ints(sorted)
+	return fmt.Sprint(sorted)
+}
+
+// budgetUsed checks the --max-decisions and --max-conflicts budgets, which
+// every solver call also checks on its own
+func budgetUsed(stats *solver.Stats) bool {
+	return (Args.MaxDecisions > 0 && stats.Decisions >= Args.MaxDecisions) ||
+		(Args.MaxConflicts > 0 && stats.Conflicts >= Args.MaxConflicts)
+}
+
+func litsFromInts(ints []int) []solver.Lit {
+	lits := make([]solver.Lit, len(ints))
+	for i, integer := range ints {
+		lits[i] = solver.MkLit(max(integer, -integer), integer < 0)
+	}
+	return lits
+}
+
+func intsFromLits(lits []solver.Lit) []int {
+	ints := make([]int, len(lits))
+	for i, lit := range lits {
+		ints[i] = lit.Var()
+		if lit.Negated() {
+			ints[i] = -ints[i]
+		}
+	}
+	return ints
+}
+
+func clauseFromInts(ints []int) *dimacsParser.Clause {
+	return solver.ClauseFromLits(litsFromInts(ints))
+}
+
+func intsFromClause(clause *dimacsParser.Clause) []int {
+	lits := make([]solver.Lit, len(clause.Vars))
+	for i, v := range clause.Vars {
+		lits[i] = solver.LitFromVariable(v)
+	}
+	return intsFromLits(lits)
+}
+
+// addClauses adds the learned clauses of a resumed state to a solver
+func addClauses(add func(lits ...solver.Lit), clauses [][]int) {
+	for _, clause := range clauses {
+		add(litsFromInts(clause)...)
+	}
+}
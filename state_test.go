@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	dimacsParser "github.com/CptPie/DLPP-solver/parser"
+	"github.com/CptPie/DLPP-solver/solver"
+)
+
+// loadTask parses a DIMACS file
+func loadTask(t *testing.T, path string) *dimacsParser.Task {
+	t.Helper()
+	p, err := dimacsParser.NewParser(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func TestMergeCubes(t *testing.T) {
+	tests := []struct {
+		name  string
+		cubes [][]int
+		want  [][]int
+	}{
+		{"nothing to merge", [][]int{{1, 2}, {-1, 3}}, [][]int{{1, 2}, {-1, 3}}},
+		{"siblings", [][]int{{1, 2}, {1, -2}}, [][]int{{1}}},
+		{"literal order", [][]int{{2, 1}, {1, -2}}, [][]int{{1}}},
+		// A stopped search leaves its cube and the siblings of its decisions
+		{"stopped search", [][]int{{1, 2, 3}, {1, 2, -3}, {1, -2}, {-1}}, [][]int{{}}},
+		{"keeps order", [][]int{{4}, {1, 2}, {5, 6}, {1, -2}}, [][]int{{4}, {1}, {5, 6}}},
+		{"duplicates", [][]int{{1, 2}, {2, 1}}, [][]int{{1, 2}}},
+		{"empty", [][]int{}, [][]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeCubes(tt.cubes)
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("mergeCubes(%v) = %v, want %v", tt.cubes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolveCubes(t *testing.T) {
+	lit := func(i int) solver.Lit { return litsFromInts([]int{i})[0] }
+	clause := func(ints ...int) *dimacsParser.Clause { return clauseFromInts(ints) }
+
+	// Cube {1} is refuted, {-1} is left open with {-1, 2} and {-1, -2, 3},
+	// the first of which is refuted before {-1, -2, 3} is found satisfiable
+	results := map[string]solver.Result{
+		"[1]":       solver.UNSATISFIABLE,
+		"[-1]":      solver.UNKNOWN,
+		"[-1 2]":    solver.UNSATISFIABLE,
+		"[-1 -2 3]": solver.SATISFIABLE,
+	}
+	var searched []string
+	solve := func(cube []solver.Lit) (solver.Result, *dimacsParser.Clause, [][]solver.Lit) {
+		key := fmt.Sprint(intsFromLits(cube))
+		searched = append(searched, key)
+		switch results[key] {
+		case solver.SATISFIABLE:
+			return solver.SATISFIABLE, clause(-1, -2, 3), nil
+		case solver.UNKNOWN:
+			return solver.UNKNOWN, nil, [][]solver.Lit{{lit(-1), lit(2)}, {lit(-1), lit(-2), lit(3)}}
+		}
+		return solver.UNSATISFIABLE, nil, nil
+	}
+
+	var stats solver.Stats
+	state := &searchState{Cubes: [][]int{{1}, {-1}}}
+	result, _ := solveCubes(context.Background(), state, &stats, solve)
+	if result != solver.UNKNOWN {
+		t.Fatalf("first run: result %s, want %s", result, solver.UNKNOWN)
+	}
+	if want := [][]int{{-1, 2}, {-1, -2, 3}}; !slices.EqualFunc(state.Cubes, want, slices.Equal) {
+		t.Fatalf("first run left cubes %v, want %v", state.Cubes, want)
+	}
+
+	result, solution := solveCubes(context.Background(), state, &stats, solve)
+	if result != solver.SATISFIABLE || solution.String() != clause(-1, -2, 3).String() {
+		t.Errorf("second run: result %s with %v", result, solution)
+	}
+	if len(state.Cubes) != 0 {
+		t.Errorf("cubes %v left after a solution", state.Cubes)
+	}
+	if want := []string{"[1]", "[-1]", "[-1 2]", "[-1 -2 3]"}; !slices.Equal(searched, want) {
+		t.Errorf("searched %v, want %v", searched, want)
+	}
+
+	// A done context stops before the next cube
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	state = newSearchState("")
+	if result, _ := solveCubes(ctx, state, &stats, solve); result != solver.UNKNOWN || len(state.Cubes) != 1 {
+		t.Errorf("cancelled run: result %s with cubes %v", result, state.Cubes)
+	}
+}
+
+func TestResumedSearchMatchesUninterruptedOne(t *testing.T) {
+	files := []string{
+		filepath.Join("examples", "uf50-218", "uf50-01.cnf"),
+		filepath.Join("examples", "uuf50-218", "uuf50-01.cnf"),
+	}
+	for _, file := range files {
+		task := loadTask(t, file)
+		full := solver.NewSolver(task, solver.DefaultOptions())
+		full.Solve(context.Background())
+
+		for _, algorithm := range []string{"dpll", "cdcl"} {
+			// Every run stops after a few decisions and dumps its state, the
+			// next one resumes it like a new process would
+			path := filepath.Join(t.TempDir(), "state.json")
+			options := solver.DefaultOptions()
+			options.MaxDecisions = 20
+			writeState(path, newSearchState(file))
+
+			result := solver.UNKNOWN
+			var solution *dimacsParser.Clause
+			runs := 0
+			for ; result == solver.UNKNOWN && runs < 1000; runs++ {
+				state := loadState(path, file)
+				var stats solver.Stats
+				if algorithm == "cdcl" {
+					s := solver.NewCDCLSolver(task, options)
+					addClauses(s.AddClause, state.Clauses)
+					result, solution = solveCubes(context.Background(), state, &stats, func(cube []solver.Lit) (solver.Result, *dimacsParser.Clause, [][]solver.Lit) {
+						s.Solve(context.Background(), cube...)
+						return s.Result, s.Solution, [][]solver.Lit{cube}
+					})
+					for _, clause := range s.LearnedClauses() {
+						state.Clauses = append(state.Clauses, intsFromLits(clause))
+					}
+				} else {
+					s := solver.NewSolver(task, options)
+					result, solution = solveCubes(context.Background(), state, &stats, func(cube []solver.Lit) (solver.Result, *dimacsParser.Clause, [][]solver.Lit) {
+						s.Solve(context.Background(), cube...)
+						return s.Result, s.Solution, s.OpenCubes()
+					})
+				}
+				writeState(path, state)
+			}
+
+			name := fmt.Sprintf("%s %s", algorithm, filepath.Base(file))
+			if result != full.Result {
+				t.Errorf("%s: result %s after %d runs, uninterrupted %s", name, result, runs, full.Result)
+				continue
+			}
+			if runs < 2 {
+				t.Errorf("%s: solved in %d run, the budget did not stop it", name, runs)
+			}
+			if result == solver.SATISFIABLE {
+				if err := solver.Verify(task, solution); err != nil {
+					t.Errorf("%s: solution does not verify: %v", name, err)
+				}
+			}
+		}
+	}
+}
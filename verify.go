@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+
+	"github.com/CptPie/DLPP-solver/logger"
+	dimacsParser "github.com/CptPie/DLPP-solver/parser"
+	"github.com/CptPie/DLPP-solver/solver"
+	"github.com/alexflint/go-arg"
+)
+
+var VerifyArgs struct {
+	File  string `arg:"required,positional" help:"Path to the input file, in DIMACS format"`
+	Model string `arg:"required,positional" help:"Path to the model, in SAT competition 'v ... 0' format or as JSON"`
+}
+
+// verifyModel checks a model, e.g. found by another solver, against the given
+// DIMACS file and reports every clause, exiting with a non-zero status if
+// the model does not satisfy all of them
+func verifyModel(args []string) {
+	p, err := arg.NewParser(arg.Config{Program: "DLPP-solver verify"}, &VerifyArgs)
+	if err != nil {
+		exitWithError("Could not create argument parser: %v\n", err)
+	}
+	p.MustParse(args)
+
+	parser, err := dimacsParser.NewParser(VerifyArgs.File)
+	if err != nil {
+		exitWithError("Parser error: %v\n", err)
+	}
+	task, err := parser.Parse()
+	if err != nil {
+		exitWithError("Parser error: %v\n", err)
+	}
+
+	model, err := dimacsParser.ParseModel(VerifyArgs.Model)
+	if err != nil {
+		exitWithError("Model error: %v\n", err)
+	}
+
+	statuses, conflicting := solver.CheckModel(task, model)
+	satisfied := 0
+	for i, status := range statuses {
+		clause := task.Clauses[i]
+		logger.Info("clause %d (line %d): %s %s\n", i, clause.Line, status, clause)
+		if status == solver.ClauseSatisfied {
+			satisfied++
+		}
+	}
+	for _, id := range conflicting {
+		logger.Info("variable %d is assigned both true and false\n", id)
+	}
+	logger.Info("%d of %d clauses satisfied\n", satisfied, len(statuses))
+
+	if satisfied < len(statuses) || len(conflicting) > 0 {
+		logger.Info("Model is NOT VALID\n")
+		os.Exit(1)
+	}
+	logger.Info("Model is VALID\n")
+}